@@ -1,7 +1,10 @@
 package gw_policies_playground
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	authorino "github.com/kuadrant/authorino/api/v1beta1"
 )
@@ -33,6 +36,10 @@ type AuthPolicy struct {
 
 	// Custom denial response codes, statuses and headers to override default 40x's.
 	DenyWith *authorino.DenyWith `json:"denyWith,omitempty"`
+
+	// Named entries of lower precedence policies to remove, e.g. `identity.anonymous` or `patterns.api-route`.
+	// These are expressed as `$remove: true` on the entry itself in the JSON representation, which decodes them sorted.
+	Removals []string `json:"-"`
 }
 
 func AuthPolicyMerger(p1, p2 AuthPolicy) AuthPolicy {
@@ -42,6 +49,16 @@ func AuthPolicyMerger(p1, p2 AuthPolicy) AuthPolicy {
 
 	names := make(map[string]interface{})
 
+	// Removals
+	for _, name := range p1.Removals {
+		names[name] = nil
+	}
+	for _, name := range append(p1.Removals, p2.Removals...) {
+		if !containsString(result.Removals, name) {
+			result.Removals = append(result.Removals, name)
+		}
+	}
+
 	// Patterns
 	for name, pattern := range p1.Patterns {
 		result.Patterns[name] = pattern
@@ -115,7 +132,10 @@ func AuthPolicyMerger(p1, p2 AuthPolicy) AuthPolicy {
 	}
 
 	// DenyWith
-	result.DenyWith = p1.DenyWith
+	if p1.DenyWith != nil {
+		denyWith := *p1.DenyWith
+		result.DenyWith = &denyWith
+	}
 
 	if denyWith := p2.DenyWith; denyWith != nil {
 		if result.DenyWith == nil {
@@ -131,3 +151,159 @@ func AuthPolicyMerger(p1, p2 AuthPolicy) AuthPolicy {
 
 	return result
 }
+
+const removeMarker = "$remove"
+
+var removableSections = []string{"identity", "metadata", "authorization", "response"}
+
+// SupportedRouteKinds excludes the L4 routes, as there's no request to authenticate nor authorize there.
+func (p AuthPolicy) SupportedRouteKinds() []RouteKind {
+	return []RouteKind{HTTPRouteKind, GRPCRouteKind}
+}
+
+// GrpcRouteAuthPolicy is the effective AuthPolicy of the route, with a condition on `context.request.http.path` so that
+// it is only enforced on the calls the route matches.
+func GrpcRouteAuthPolicy(route *GrpcRoute[AuthPolicy]) AuthPolicy {
+	policy := route.MergedPolicies(AuthPolicyMerger)
+	if len(route.matches) == 0 {
+		return policy
+	}
+	operator, value := grpcPathPattern(route.matches)
+	policy.Conditions = append(append([]authorino.JSONPattern{}, policy.Conditions...), authorino.JSONPattern{
+		JSONPatternExpression: authorino.JSONPatternExpression{
+			Selector: "context.request.http.path",
+			Operator: authorino.JSONPatternOperator(operator),
+			Value:    value,
+		},
+	})
+	return policy
+}
+
+func (p *AuthPolicy) UnmarshalJSON(data []byte) error {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return err
+	}
+
+	var removals []string
+	if raw, exists := sections["patterns"]; exists {
+		var patterns map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &patterns); err != nil {
+			return fmt.Errorf("patterns: %w", err)
+		}
+		for name, pattern := range patterns {
+			if isRemoval(pattern) {
+				removals = append(removals, fmt.Sprintf("patterns.%s", name))
+				delete(patterns, name)
+			}
+		}
+		sections["patterns"], _ = json.Marshal(patterns)
+	}
+	for _, section := range removableSections {
+		raw, exists := sections[section]
+		if !exists {
+			continue
+		}
+		var entries []json.RawMessage
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return fmt.Errorf("%s: %w", section, err)
+		}
+		kept := make([]json.RawMessage, 0, len(entries))
+		for _, entry := range entries {
+			if !isRemoval(entry) {
+				kept = append(kept, entry)
+				continue
+			}
+			var named struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(entry, &named); err != nil || named.Name == "" {
+				return fmt.Errorf("%s: %s entries require a name", section, removeMarker)
+			}
+			removals = append(removals, fmt.Sprintf("%s.%s", section, named.Name))
+		}
+		sections[section], _ = json.Marshal(kept)
+	}
+
+	cleaned, err := json.Marshal(sections)
+	if err != nil {
+		return err
+	}
+	type authPolicy AuthPolicy
+	var policy authPolicy
+	if err := json.Unmarshal(cleaned, &policy); err != nil {
+		return err
+	}
+	*p = AuthPolicy(policy)
+	sort.Strings(removals)
+	p.Removals = removals
+	return nil
+}
+
+func (p AuthPolicy) MarshalJSON() ([]byte, error) {
+	type authPolicy AuthPolicy
+	raw, err := json.Marshal(authPolicy(p))
+	if err != nil || len(p.Removals) == 0 {
+		return raw, err
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &sections); err != nil {
+		return nil, err
+	}
+	patterns := make(map[string]json.RawMessage)
+	entries := make(map[string][]json.RawMessage)
+	for _, removal := range p.Removals {
+		parts := strings.SplitN(removal, ".", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid removal %q", removal)
+		}
+		section, name := parts[0], parts[1]
+		if section == "patterns" {
+			patterns[name] = json.RawMessage(fmt.Sprintf(`{%q:true}`, removeMarker))
+		} else {
+			entry, _ := json.Marshal(map[string]interface{}{"name": name, removeMarker: true})
+			entries[section] = append(entries[section], entry)
+		}
+	}
+	if len(patterns) > 0 {
+		existing := make(map[string]json.RawMessage)
+		if raw, exists := sections["patterns"]; exists {
+			if err := json.Unmarshal(raw, &existing); err != nil {
+				return nil, err
+			}
+		}
+		for name, pattern := range patterns {
+			existing[name] = pattern
+		}
+		sections["patterns"], _ = json.Marshal(existing)
+	}
+	for section, removed := range entries {
+		var existing []json.RawMessage
+		if raw, exists := sections[section]; exists {
+			if err := json.Unmarshal(raw, &existing); err != nil {
+				return nil, err
+			}
+		}
+		sections[section], _ = json.Marshal(append(existing, removed...))
+	}
+	return json.Marshal(sections)
+}
+
+func isRemoval(raw json.RawMessage) bool {
+	var marker map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &marker); err != nil {
+		return false
+	}
+	var remove bool
+	return json.Unmarshal(marker[removeMarker], &remove) == nil && remove
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+)
+
+// OidcIssuerConstraint requires all the identities to be OIDC ones, from issuer.
+func OidcIssuerConstraint(issuer string, reject bool) Constraint[AuthPolicy] {
+	return NewConstraint("oidc-issuer", func(policy AuthPolicy) []string {
+		var violations []string
+		for _, identity := range policy.Identity {
+			switch {
+			case identity.Oidc == nil:
+				violations = append(violations, fmt.Sprintf("identity %s isn't OIDC", identity.Name))
+			case identity.Oidc.Endpoint != issuer:
+				violations = append(violations, fmt.Sprintf("identity %s trusts issuer %s, not %s", identity.Name, identity.Oidc.Endpoint, issuer))
+			}
+		}
+		return violations
+	}, reject)
+}
+
+func NoAnonymousIdentityConstraint(reject bool) Constraint[AuthPolicy] {
+	return NewConstraint("no-anonymous-identity", func(policy AuthPolicy) []string {
+		var violations []string
+		for _, identity := range policy.Identity {
+			if identity.Anonymous != nil {
+				violations = append(violations, fmt.Sprintf("identity %s is anonymous", identity.Name))
+			}
+		}
+		return violations
+	}, reject)
+}
+
+// DenyWithCodesConstraint restricts the status codes of denied requests to codes.
+func DenyWithCodesConstraint(reject bool, codes ...int) Constraint[AuthPolicy] {
+	return NewConstraint("deny-with-codes", func(policy AuthPolicy) []string {
+		if policy.DenyWith == nil {
+			return nil
+		}
+		var violations []string
+		for _, denial := range []struct {
+			name string
+			spec *authorino.DenyWithSpec
+		}{{"unauthenticated", policy.DenyWith.Unauthenticated}, {"unauthorized", policy.DenyWith.Unauthorized}} {
+			if denial.spec == nil || denial.spec.Code == 0 {
+				continue
+			}
+			allowed := false
+			for _, code := range codes {
+				if int(denial.spec.Code) == code {
+					allowed = true
+				}
+			}
+			if !allowed {
+				violations = append(violations, fmt.Sprintf("%s requests are denied with %d, not one of %v", denial.name, denial.spec.Code, codes))
+			}
+		}
+		return violations
+	}, reject)
+}
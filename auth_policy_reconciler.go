@@ -0,0 +1,218 @@
+package gw_policies_playground
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+const (
+	// ManagedByLabel marks the AuthConfigs the AuthPolicyReconciler owns.
+	ManagedByLabel = "gw-policies-playground.kuadrant.io/managed-by"
+	managedBy      = "auth-policy-reconciler"
+
+	RouteLabel   = "gw-policies-playground.kuadrant.io/route"
+	GatewayLabel = "gw-policies-playground.kuadrant.io/gateway"
+)
+
+// AuthPolicyReconciler keeps an Authorino AuthConfig per HTTPRoute or GRPCRoute and parent Gateway, holding the
+// effective AuthPolicy of the route, restricted to the calls it matches for a GRPCRoute. Any change to a Gateway, a
+// route or an AuthPolicy rebuilds the whole hierarchy. TCPRoutes and TLSRoutes are only part of it for the status of the
+// policies inherited there, which don't apply to them.
+type AuthPolicyReconciler struct {
+	Client client.Client
+}
+
+func (r *AuthPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	hierarchy := handler.EnqueueRequestsFromMapFunc(func(client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "hierarchy"}}}
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("authpolicy").
+		For(&PolicyCR[AuthPolicy]{}).
+		Watches(&source.Kind{Type: &gatewayapi.Gateway{}}, hierarchy).
+		Watches(&source.Kind{Type: &gatewayapi.HTTPRoute{}}, hierarchy).
+		Watches(&source.Kind{Type: &gatewayapi.GRPCRoute{}}, hierarchy).
+		Watches(&source.Kind{Type: &gatewayapi.TCPRoute{}}, hierarchy).
+		Watches(&source.Kind{Type: &gatewayapi.TLSRoute{}}, hierarchy).
+		Watches(&source.Kind{Type: &authorino.AuthConfig{}}, hierarchy).
+		Complete(r)
+}
+
+func (r *AuthPolicyReconciler) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	var gateways gatewayapi.GatewayList
+	if err := r.Client.List(ctx, &gateways); err != nil {
+		return reconcile.Result{}, err
+	}
+	var routes gatewayapi.HTTPRouteList
+	if err := r.Client.List(ctx, &routes); err != nil {
+		return reconcile.Result{}, err
+	}
+	var grpcRoutes gatewayapi.GRPCRouteList
+	if err := r.Client.List(ctx, &grpcRoutes); err != nil {
+		return reconcile.Result{}, err
+	}
+	var tcpRoutes gatewayapi.TCPRouteList
+	if err := r.Client.List(ctx, &tcpRoutes); err != nil {
+		return reconcile.Result{}, err
+	}
+	var tlsRoutes gatewayapi.TLSRouteList
+	if err := r.Client.List(ctx, &tlsRoutes); err != nil {
+		return reconcile.Result{}, err
+	}
+	var policies PolicyCRList[AuthPolicy]
+	if err := r.Client.List(ctx, &policies); err != nil {
+		return reconcile.Result{}, err
+	}
+	topology := NewTopology(gateways.Items, routes.Items, grpcRoutes.Items, tcpRoutes.Items, tlsRoutes.Items, policies.Items)
+
+	if err := r.reconcileAuthConfigs(ctx, desiredAuthConfigs(topology)); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.reconcileStatuses(ctx, policies.Items, topology.PolicyStatuses(AuthPolicyMerger)); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *AuthPolicyReconciler) reconcileAuthConfigs(ctx context.Context, desired map[types.NamespacedName]*authorino.AuthConfig) error {
+	var existing authorino.AuthConfigList
+	if err := r.Client.List(ctx, &existing, client.MatchingLabels{ManagedByLabel: managedBy}); err != nil {
+		return err
+	}
+	for i := range existing.Items {
+		current := &existing.Items[i]
+		key := types.NamespacedName{Namespace: current.Namespace, Name: current.Name}
+		want, exists := desired[key]
+		if !exists {
+			if err := client.IgnoreNotFound(r.Client.Delete(ctx, current)); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(desired, key)
+		if equality.Semantic.DeepEqual(current.Spec, want.Spec) && equality.Semantic.DeepEqual(current.Labels, want.Labels) {
+			continue
+		}
+		current.Spec = want.Spec
+		current.Labels = want.Labels
+		if err := r.Client.Update(ctx, current); err != nil {
+			return err
+		}
+	}
+	for _, authConfig := range desired {
+		if err := r.Client.Create(ctx, authConfig); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileStatuses updates the status of the policies, keeping the transition time of the conditions that hold.
+func (r *AuthPolicyReconciler) reconcileStatuses(ctx context.Context, policies []PolicyCR[AuthPolicy], statuses map[types.NamespacedName]PolicyStatus) error {
+	for i := range policies {
+		policy := &policies[i]
+		status := statuses[types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}]
+		for j := range status.Ancestors {
+			ancestor := &status.Ancestors[j]
+			for _, previous := range policy.Status.Ancestors {
+				if !equality.Semantic.DeepEqual(previous.AncestorRef, ancestor.AncestorRef) {
+					continue
+				}
+				for k := range ancestor.Conditions {
+					condition := &ancestor.Conditions[k]
+					if existing := meta.FindStatusCondition(previous.Conditions, condition.Type); existing != nil && existing.Status == condition.Status {
+						condition.LastTransitionTime = existing.LastTransitionTime
+					}
+				}
+			}
+		}
+		if equality.Semantic.DeepEqual(policy.Status, status) {
+			continue
+		}
+		policy.Status = status
+		if err := r.Client.Status().Update(ctx, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func desiredAuthConfigs(topology *Topology[AuthPolicy]) map[types.NamespacedName]*authorino.AuthConfig {
+	desired := make(map[types.NamespacedName]*authorino.AuthConfig)
+	add := func(authConfig *authorino.AuthConfig) {
+		if authConfig != nil {
+			desired[types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}] = authConfig
+		}
+	}
+	for key, parents := range topology.Routes {
+		for _, route := range parents {
+			add(routeAuthConfig(key, HTTPRouteKind, route.parent.name, route.Hosts(), route.MergedPolicies(AuthPolicyMerger)))
+		}
+	}
+	for key, parents := range topology.GRPCRoutes {
+		for _, route := range parents {
+			add(routeAuthConfig(key, GRPCRouteKind, route.parent.name, route.Hosts(), GrpcRouteAuthPolicy(route)))
+		}
+	}
+	return desired
+}
+
+// routeAuthConfig is the AuthConfig enforcing the policy of a route on the hosts it serves on a gateway, if any.
+func routeAuthConfig(key types.NamespacedName, kind RouteKind, gateway string, hosts []string, policy AuthPolicy) *authorino.AuthConfig {
+	if len(hosts) == 0 || len(DiffPolicies(AuthPolicy{}, policy)) == 0 {
+		return nil
+	}
+	return &authorino.AuthConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: authorino.GroupVersion.String(),
+			Kind:       "AuthConfig",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: key.Namespace,
+			Name:      authConfigName(kind, key.Name, gateway),
+			Labels: map[string]string{
+				ManagedByLabel: managedBy,
+				RouteLabel:     key.Name,
+				GatewayLabel:   strings.ReplaceAll(gateway, "/", "."),
+			},
+		},
+		Spec: authConfigSpec(policy, hosts),
+	}
+}
+
+// authConfigName is `<route>.<gateway namespace>.<gateway>`, as a route can have many parent gateways. The names of the
+// ones of GRPCRoutes are prefixed with `grpc.`, not to clash with the ones of HTTPRoutes.
+func authConfigName(kind RouteKind, route, gateway string) string {
+	name := fmt.Sprintf("%s.%s", route, strings.ReplaceAll(gateway, "/", "."))
+	if kind == GRPCRouteKind {
+		return "grpc." + name
+	}
+	return name
+}
+
+func authConfigSpec(policy AuthPolicy, hosts []string) authorino.AuthConfigSpec {
+	return authorino.AuthConfigSpec{
+		Hosts:         hosts,
+		Patterns:      policy.Patterns,
+		Conditions:    policy.Conditions,
+		Identity:      policy.Identity,
+		Metadata:      policy.Metadata,
+		Authorization: policy.Authorization,
+		Response:      policy.Response,
+		DenyWith:      policy.DenyWith,
+	}
+}
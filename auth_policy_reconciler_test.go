@@ -0,0 +1,248 @@
+package gw_policies_playground
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+var testCreationTime = metav1.NewTime(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, gatewayapi.AddToScheme(scheme))
+	assert.NilError(t, authorino.AddToScheme(scheme))
+	assert.NilError(t, AddToScheme(scheme))
+	return scheme
+}
+
+func testGateway(namespace, name string) *gatewayapi.Gateway {
+	hostname := gatewayapi.Hostname("*.toystore.io")
+	return &gatewayapi.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: gatewayapi.GatewaySpec{
+			GatewayClassName: "istio",
+			Listeners: []gatewayapi.Listener{
+				{Name: "http", Hostname: &hostname, Port: 80, Protocol: "HTTP"},
+			},
+		},
+	}
+}
+
+func testHTTPRoute(namespace, name string, gateways ...types.NamespacedName) *gatewayapi.HTTPRoute {
+	route := &gatewayapi.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+	}
+	for _, gateway := range gateways {
+		gatewayNamespace := gatewayapi.Namespace(gateway.Namespace)
+		route.Spec.ParentRefs = append(route.Spec.ParentRefs, gatewayapi.ParentReference{
+			Namespace: &gatewayNamespace,
+			Name:      gatewayapi.ObjectName(gateway.Name),
+		})
+	}
+	return route
+}
+
+func newTestAuthPolicyCR(namespace, name, kind, target string, defaults, overrides *AuthPolicy, age time.Duration) *PolicyCR[AuthPolicy] {
+	return &PolicyCR[AuthPolicy]{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(testCreationTime.Add(age)),
+		},
+		Spec: PolicyCRSpec[AuthPolicy]{
+			TargetRef: PolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: kind, Name: target},
+			Defaults:  defaults,
+			Overrides: overrides,
+		},
+	}
+}
+
+func reconcileAuthPolicies(t *testing.T, c client.Client) []authorino.AuthConfig {
+	reconciler := &AuthPolicyReconciler{Client: c}
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{})
+	assert.NilError(t, err)
+
+	var authConfigs authorino.AuthConfigList
+	assert.NilError(t, c.List(context.Background(), &authConfigs))
+	return authConfigs.Items
+}
+
+func TestAuthPolicyReconciler(t *testing.T) {
+	gw := types.NamespacedName{Namespace: "gateway-system", Name: "gw"}
+	routePolicy := newTestAuthPolicyCR("toystore", "route-policy", "HTTPRoute", "toystore", nil, &testAuthPolicySpec2, time.Hour)
+	unmanaged := &authorino.AuthConfig{ObjectMeta: metav1.ObjectMeta{Namespace: "toystore", Name: "hand-written"}}
+	stale := &authorino.AuthConfig{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "toystore",
+		Name:      "gone",
+		Labels:    map[string]string{ManagedByLabel: managedBy},
+	}}
+
+	c := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(
+		testGateway(gw.Namespace, gw.Name),
+		testHTTPRoute("toystore", "toystore", gw),
+		testHTTPRoute("toystore", "orphan", types.NamespacedName{Namespace: "toystore", Name: "missing"}),
+		newTestAuthPolicyCR("gateway-system", "gw-policy", "Gateway", "gw", &testAuthPolicySpec1, nil, 0),
+		routePolicy,
+		unmanaged,
+		stale,
+	).Build()
+
+	authConfigs := reconcileAuthPolicies(t, c)
+
+	assert.Equal(t, len(authConfigs), 2)
+	assert.Equal(t, authConfigs[0].Name, "hand-written")
+	authConfig := authConfigs[1]
+	assert.Equal(t, authConfig.Name, "toystore.gateway-system.gw")
+	assert.Equal(t, authConfig.Labels[RouteLabel], "toystore")
+	assert.Equal(t, authConfig.Labels[GatewayLabel], "gateway-system.gw")
+	assert.DeepEqual(t, authConfig.Spec.Hosts, []string{"*.toystore.io"})
+	assert.Equal(t, len(authConfig.Spec.Identity), 1)
+	assert.Check(t, authConfig.Spec.Identity[0].APIKey != nil)
+	assert.Equal(t, authConfig.Spec.Patterns["api-version"][0].Value, "v1")
+	assert.Check(t, authConfig.Spec.Patterns["api-route"] != nil)
+	assert.Equal(t, int(authConfig.Spec.DenyWith.Unauthorized.Code), 302)
+
+	var gwPolicy PolicyCR[AuthPolicy]
+	assert.NilError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "gateway-system", Name: "gw-policy"}, &gwPolicy))
+	assert.Equal(t, len(gwPolicy.Status.Ancestors), 1)
+	overridden := meta.FindStatusCondition(gwPolicy.Status.Ancestors[0].Conditions, PolicyConditionOverridden)
+	assert.Equal(t, overridden.Reason, PolicyReasonPartiallyOverridden)
+	transition := overridden.LastTransitionTime
+
+	_, err := (&AuthPolicyReconciler{Client: c}).Reconcile(context.Background(), reconcile.Request{})
+	assert.NilError(t, err)
+	assert.NilError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "gateway-system", Name: "gw-policy"}, &gwPolicy))
+	overridden = meta.FindStatusCondition(gwPolicy.Status.Ancestors[0].Conditions, PolicyConditionOverridden)
+	assert.Equal(t, overridden.LastTransitionTime, transition)
+
+	assert.NilError(t, c.Delete(context.Background(), routePolicy))
+	authConfigs = reconcileAuthPolicies(t, c)
+
+	assert.Equal(t, len(authConfigs), 2)
+	authConfig = authConfigs[1]
+	assert.Check(t, authConfig.Spec.Identity[0].Anonymous != nil)
+	assert.Equal(t, authConfig.Spec.Patterns["api-version"][0].Value, "^v[0-9]+")
+	assert.Check(t, authConfig.Spec.DenyWith.Unauthorized.Code == 0)
+	assert.NilError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "gateway-system", Name: "gw-policy"}, &gwPolicy))
+	overridden = meta.FindStatusCondition(gwPolicy.Status.Ancestors[0].Conditions, PolicyConditionOverridden)
+	assert.Equal(t, overridden.Reason, PolicyReasonEnforced)
+
+	assert.NilError(t, c.Delete(context.Background(), testHTTPRoute("toystore", "toystore")))
+	authConfigs = reconcileAuthPolicies(t, c)
+
+	assert.Equal(t, len(authConfigs), 1)
+	assert.Equal(t, authConfigs[0].Name, "hand-written")
+}
+
+func TestAuthPolicyReconciler_ManyParents(t *testing.T) {
+	gw1 := types.NamespacedName{Namespace: "gateway-system", Name: "gw1"}
+	gw2 := types.NamespacedName{Namespace: "gateway-system", Name: "gw2"}
+	route := testHTTPRoute("toystore", "toystore", gw1, gw2)
+	route.Spec.Hostnames = []gatewayapi.Hostname{"api.toystore.io"}
+
+	c := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(
+		testGateway(gw1.Namespace, gw1.Name),
+		testGateway(gw2.Namespace, gw2.Name),
+		route,
+		newTestAuthPolicyCR("gateway-system", "gw-policy", "Gateway", "gw2", nil, &testAuthPolicySpec2, 0),
+		newTestAuthPolicyCR("toystore", "route-policy", "HTTPRoute", "toystore", &testAuthPolicySpec1, nil, time.Hour),
+	).Build()
+
+	authConfigs := reconcileAuthPolicies(t, c)
+
+	assert.Equal(t, len(authConfigs), 2)
+	assert.Equal(t, authConfigs[0].Name, "toystore.gateway-system.gw1")
+	assert.DeepEqual(t, authConfigs[0].Spec.Hosts, []string{"api.toystore.io"})
+	assert.Check(t, authConfigs[0].Spec.Identity[0].Anonymous != nil)
+	assert.Equal(t, authConfigs[1].Name, "toystore.gateway-system.gw2")
+	assert.Check(t, authConfigs[1].Spec.Identity[0].APIKey != nil)
+
+	before := authConfigs[1].ResourceVersion
+	authConfigs = reconcileAuthPolicies(t, c)
+	assert.Equal(t, authConfigs[1].ResourceVersion, before)
+}
+
+func TestAuthPolicyReconciler_Hostnames(t *testing.T) {
+	gw := types.NamespacedName{Namespace: "gateway-system", Name: "gw"}
+	api := testHTTPRoute("toystore", "api", gw)
+	api.Spec.Hostnames = []gatewayapi.Hostname{"*.api.toystore.io", "api.other.io"}
+	other := testHTTPRoute("toystore", "other", gw)
+	other.Spec.Hostnames = []gatewayapi.Hostname{"api.other.io"}
+
+	c := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(
+		testGateway(gw.Namespace, gw.Name),
+		api,
+		other,
+		newTestAuthPolicyCR("gateway-system", "gw-policy", "Gateway", "gw", &testAuthPolicySpec1, nil, 0),
+	).Build()
+
+	authConfigs := reconcileAuthPolicies(t, c)
+
+	assert.Equal(t, len(authConfigs), 1)
+	assert.Equal(t, authConfigs[0].Name, "api.gateway-system.gw")
+	assert.DeepEqual(t, authConfigs[0].Spec.Hosts, []string{"*.api.toystore.io"})
+}
+
+func TestAuthPolicyReconciler_GRPCRoute(t *testing.T) {
+	gw := types.NamespacedName{Namespace: "gateway-system", Name: "gw"}
+	gatewayNamespace := gatewayapi.Namespace(gw.Namespace)
+	service, method := "toystore.Toys", "List"
+	grpcRoute := &gatewayapi.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "toystore", Name: "toystore"},
+		Spec: gatewayapi.GRPCRouteSpec{
+			CommonRouteSpec: gatewayapi.CommonRouteSpec{ParentRefs: []gatewayapi.ParentReference{
+				{Namespace: &gatewayNamespace, Name: gatewayapi.ObjectName(gw.Name)},
+			}},
+			Hostnames: []gatewayapi.Hostname{"grpc.toystore.io"},
+			Rules: []gatewayapi.GRPCRouteRule{{Matches: []gatewayapi.GRPCRouteMatch{
+				{Method: &gatewayapi.GRPCMethodMatch{Service: &service, Method: &method}},
+			}}},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(
+		testGateway(gw.Namespace, gw.Name),
+		testHTTPRoute("toystore", "toystore", gw),
+		grpcRoute,
+		newTestAuthPolicyCR("gateway-system", "gw-policy", "Gateway", "gw", &testAuthPolicySpec1, nil, 0),
+		newTestAuthPolicyCR("toystore", "grpc-policy", "GRPCRoute", "toystore", nil, &testAuthPolicySpec2, time.Hour),
+	).Build()
+
+	authConfigs := reconcileAuthPolicies(t, c)
+
+	assert.Equal(t, len(authConfigs), 2)
+	authConfig := authConfigs[0]
+	assert.Equal(t, authConfig.Name, "grpc.toystore.gateway-system.gw")
+	assert.DeepEqual(t, authConfig.Spec.Hosts, []string{"grpc.toystore.io"})
+	assert.Check(t, authConfig.Spec.Identity[0].APIKey != nil)
+	path := authConfig.Spec.Conditions[len(authConfig.Spec.Conditions)-1]
+	assert.Equal(t, path.Selector, "context.request.http.path")
+	assert.Equal(t, string(path.Operator), "eq")
+	assert.Equal(t, path.Value, "/toystore.Toys/List")
+	assert.Equal(t, authConfigs[1].Name, "toystore.gateway-system.gw")
+	assert.Check(t, authConfigs[1].Spec.Identity[0].Anonymous != nil)
+
+	var grpcPolicy PolicyCR[AuthPolicy]
+	assert.NilError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "toystore", Name: "grpc-policy"}, &grpcPolicy))
+	accepted := meta.FindStatusCondition(grpcPolicy.Status.Ancestors[0].Conditions, PolicyConditionAccepted)
+	assert.Equal(t, accepted.Status, metav1.ConditionTrue)
+
+	assert.NilError(t, c.Delete(context.Background(), grpcRoute))
+	authConfigs = reconcileAuthPolicies(t, c)
+
+	assert.Equal(t, len(authConfigs), 1)
+	assert.Equal(t, authConfigs[0].Name, "toystore.gateway-system.gw")
+}
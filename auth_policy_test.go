@@ -1,6 +1,7 @@
 package gw_policies_playground
 
 import (
+	"encoding/json"
 	"testing"
 
 	authorino "github.com/kuadrant/authorino/api/v1beta1"
@@ -107,7 +108,7 @@ var (
 					{
 						Name: "Location",
 						Value: k8s.RawExtension{
-							Raw: []byte("https://my-app.io/login"),
+							Raw: []byte(`"https://my-app.io/login"`),
 						},
 					},
 				},
@@ -416,3 +417,131 @@ func TestMerge_GatewayOverride_RouteOverride(t *testing.T) {
 	assert.Check(t, result.DenyWith.Unauthorized != nil)
 	assert.Equal(t, int(result.DenyWith.Unauthorized.Code), 0)
 }
+
+func TestMerge_GatewayClassOverrideRemoval_RouteDefault(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	p1 := PolicySpec[AuthPolicy]{
+		name:      "no-anonymous",
+		overrides: AuthPolicy{Removals: []string{"identity.friends", "patterns.api-route"}},
+	}
+	gwc.AddPolicy(p1)
+
+	p2 := PolicySpec[AuthPolicy]{
+		name:     "auth-policy",
+		defaults: testAuthPolicySpec1,
+	}
+	route.AddPolicy(p2)
+
+	result := route.MergedPolicies(AuthPolicyMerger)
+
+	assert.Check(t, result.Patterns["api-route"] == nil)
+	assert.Check(t, result.Patterns["api-version"] != nil)
+	assert.Equal(t, len(result.Identity), 0)
+	assert.DeepEqual(t, result.Removals, []string{"identity.friends", "patterns.api-route"})
+}
+
+func TestMerge_GatewayDefault_RouteDefaultRemoval(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	p1 := PolicySpec[AuthPolicy]{
+		name: "auth-policy",
+		defaults: AuthPolicy{
+			Metadata: []*authorino.Metadata{
+				{
+					Name:     "user-info",
+					UserInfo: &authorino.Metadata_UserInfo{IdentitySource: "sso"},
+				},
+			},
+			Authorization: testAuthPolicySpec2.Authorization,
+		},
+	}
+	gw.AddPolicy(p1)
+
+	p2 := PolicySpec[AuthPolicy]{
+		name:     "auth-policy",
+		defaults: AuthPolicy{Removals: []string{"metadata.user-info"}},
+	}
+	route.AddPolicy(p2)
+
+	result := route.MergedPolicies(AuthPolicyMerger)
+
+	assert.Equal(t, len(result.Metadata), 0)
+	assert.Equal(t, len(result.Authorization), 1)
+	assert.Equal(t, result.Authorization[0].Name, "my-policy")
+}
+
+func TestMerge_GatewayDefaultRemoval_RouteOverride(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	p1 := PolicySpec[AuthPolicy]{
+		name:     "auth-policy",
+		defaults: AuthPolicy{Removals: []string{"identity.friends", "authorization.my-policy"}},
+	}
+	gw.AddPolicy(p1)
+
+	p2 := PolicySpec[AuthPolicy]{
+		name:      "auth-policy",
+		overrides: testAuthPolicySpec2,
+	}
+	route.AddPolicy(p2)
+
+	result := route.MergedPolicies(AuthPolicyMerger)
+
+	assert.Equal(t, len(result.Identity), 1)
+	assert.Check(t, result.Identity[0].APIKey != nil)
+	assert.Equal(t, len(result.Authorization), 1)
+}
+
+func TestAuthPolicy_RemovalMarkers(t *testing.T) {
+	var policy AuthPolicy
+	err := json.Unmarshal([]byte(`{
+		"patterns": {
+			"api-route": {"$remove": true},
+			"api-version": [{"selector": "context.request.http.method", "operator": "eq", "value": "GET"}]
+		},
+		"identity": [
+			{"name": "anonymous", "$remove": true},
+			{"name": "friends", "anonymous": {}}
+		],
+		"metadata": [{"name": "user-info", "$remove": true}]
+	}`), &policy)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(policy.Patterns), 1)
+	assert.Check(t, policy.Patterns["api-version"] != nil)
+	assert.Equal(t, len(policy.Identity), 1)
+	assert.Equal(t, policy.Identity[0].Name, "friends")
+	assert.Equal(t, len(policy.Metadata), 0)
+	assert.DeepEqual(t, policy.Removals, []string{"identity.anonymous", "metadata.user-info", "patterns.api-route"})
+
+	raw, err := json.Marshal(policy)
+	assert.NilError(t, err)
+
+	var reloaded AuthPolicy
+	assert.NilError(t, json.Unmarshal(raw, &reloaded))
+	assert.DeepEqual(t, reloaded.Removals, policy.Removals)
+	assert.Equal(t, len(DiffPolicies(policy, reloaded)), 0)
+
+	err = json.Unmarshal([]byte(`{"identity": [{"$remove": true}]}`), &policy)
+	assert.ErrorContains(t, err, "require a name")
+}
+
+func TestAuthPolicy_RemovalMarkersOrder(t *testing.T) {
+	data := []byte(`{
+		"patterns": {"c": {"$remove": true}, "a": {"$remove": true}, "d": {"$remove": true}, "b": {"$remove": true}},
+		"identity": [{"name": "z", "$remove": true}, {"name": "anonymous", "$remove": true}]
+	}`)
+
+	for i := 0; i < 20; i++ {
+		var policy AuthPolicy
+		assert.NilError(t, json.Unmarshal(data, &policy))
+		assert.DeepEqual(t, policy.Removals, []string{"identity.anonymous", "identity.z", "patterns.a", "patterns.b", "patterns.c", "patterns.d"})
+	}
+}
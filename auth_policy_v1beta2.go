@@ -0,0 +1,562 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+	k8s "k8s.io/apimachinery/pkg/runtime"
+)
+
+// AuthPolicyV1beta2 is an AuthPolicy in the shape of Authorino's v1beta2 AuthConfig: the named entries of each section
+// are maps keyed by name, identity is authentication, and denyWith is part of response. Authorino's v1beta2 API
+// requires a version of controller-runtime this module can't use alongside the v1beta1 one, so the types are
+// mirrored here, reusing the v1beta1 ones for the leaves that didn't change shape.
+type AuthPolicyV1beta2 struct {
+	Patterns       map[string]authorino.JSONPatternExpressions `json:"patterns,omitempty"`
+	Conditions     []authorino.JSONPattern                     `json:"when,omitempty"`
+	Authentication map[string]AuthenticationSpec               `json:"authentication,omitempty"`
+	Metadata       map[string]MetadataSpec                     `json:"metadata,omitempty"`
+	Authorization  map[string]AuthorizationSpec                `json:"authorization,omitempty"`
+	Response       *ResponseSpec                               `json:"response,omitempty"`
+
+	// Removals are tombstones as for AuthPolicy, keyed by `authentication.<name>` rather than `identity.<name>`, and
+	// `response.<name>` removing the success response of that name, be it a header or dynamic metadata. These are
+	// expressed as `$remove: true` on the entry itself in the JSON representation, success responses as headers.
+	Removals []string `json:"-"`
+}
+
+type CommonEvaluatorSpec struct {
+	Priority   int                         `json:"priority,omitempty"`
+	Metrics    bool                        `json:"metrics,omitempty"`
+	Conditions []authorino.JSONPattern     `json:"when,omitempty"`
+	Cache      *authorino.EvaluatorCaching `json:"cache,omitempty"`
+}
+
+type ValueOrSelector struct {
+	Value    k8s.RawExtension `json:"value,omitempty"`
+	Selector string           `json:"selector,omitempty"`
+}
+
+type AuthenticationSpec struct {
+	CommonEvaluatorSpec `json:",inline"`
+
+	Credentials authorino.Credentials `json:"credentials,omitempty"`
+
+	// Defaults are the v1beta1 extendedProperties.
+	Defaults map[string]ValueOrSelector `json:"defaults,omitempty"`
+
+	ApiKey                   *authorino.Identity_APIKey         `json:"apiKey,omitempty"`
+	Jwt                      *JwtAuthenticationSpec             `json:"jwt,omitempty"`
+	OAuth2TokenIntrospection *authorino.Identity_OAuth2Config   `json:"oauth2Introspection,omitempty"`
+	KubernetesTokenReview    *authorino.Identity_KubernetesAuth `json:"kubernetesTokenReview,omitempty"`
+	X509ClientCertificate    *authorino.Identity_MTLS           `json:"x509,omitempty"`
+	Plain                    *authorino.Identity_Plain          `json:"plain,omitempty"`
+	AnonymousAccess          *authorino.Identity_Anonymous      `json:"anonymous,omitempty"`
+}
+
+// JwtAuthenticationSpec is the v1beta1 oidc identity.
+type JwtAuthenticationSpec struct {
+	IssuerUrl string `json:"issuerUrl"`
+	TTL       int    `json:"ttl,omitempty"`
+}
+
+type MetadataSpec struct {
+	CommonEvaluatorSpec `json:",inline"`
+
+	Http     *authorino.Metadata_GenericHTTP `json:"http,omitempty"`
+	UserInfo *authorino.Metadata_UserInfo    `json:"userInfo,omitempty"`
+	Uma      *authorino.Metadata_UMA         `json:"uma,omitempty"`
+}
+
+type AuthorizationSpec struct {
+	CommonEvaluatorSpec `json:",inline"`
+
+	PatternMatching               *PatternMatchingAuthorizationSpec        `json:"patternMatching,omitempty"`
+	Opa                           *authorino.Authorization_OPA             `json:"opa,omitempty"`
+	KubernetesSubjectAccessReview *authorino.Authorization_KubernetesAuthz `json:"kubernetesSubjectAccessReview,omitempty"`
+}
+
+// PatternMatchingAuthorizationSpec is the v1beta1 json authorization.
+type PatternMatchingAuthorizationSpec struct {
+	Patterns []authorino.JSONPattern `json:"patterns"`
+}
+
+type ResponseSpec struct {
+	Unauthenticated *authorino.DenyWithSpec    `json:"unauthenticated,omitempty"`
+	Unauthorized    *authorino.DenyWithSpec    `json:"unauthorized,omitempty"`
+	Success         WrappedSuccessResponseSpec `json:"success,omitempty"`
+}
+
+// WrappedSuccessResponseSpec splits the v1beta1 responses by wrapper.
+type WrappedSuccessResponseSpec struct {
+	Headers         map[string]SuccessResponseSpec `json:"headers,omitempty"`
+	DynamicMetadata map[string]SuccessResponseSpec `json:"dynamicMetadata,omitempty"`
+}
+
+type SuccessResponseSpec struct {
+	CommonEvaluatorSpec `json:",inline"`
+
+	// Key is the v1beta1 wrapperKey.
+	Key string `json:"key,omitempty"`
+
+	Wristband *authorino.Response_Wristband   `json:"wristband,omitempty"`
+	Json      *authorino.Response_DynamicJSON `json:"json,omitempty"`
+}
+
+const (
+	httpHeaderWrapper           authorino.Response_Wrapper = "httpHeader"
+	envoyDynamicMetadataWrapper authorino.Response_Wrapper = "envoyDynamicMetadata"
+)
+
+// AuthPolicyV1beta2Merger is the AuthPolicyMerger of the v1beta2 shape: p1 takes precedence over p2, entry by entry.
+func AuthPolicyV1beta2Merger(p1, p2 AuthPolicyV1beta2) AuthPolicyV1beta2 {
+	removed := make(map[string]bool, len(p1.Removals))
+	var removals []string
+	for _, name := range p1.Removals {
+		removed[name] = true
+	}
+	for _, name := range append(p1.Removals, p2.Removals...) {
+		if !containsString(removals, name) {
+			removals = append(removals, name)
+		}
+	}
+
+	result := AuthPolicyV1beta2{
+		Patterns:       mergeEntries("patterns", p1.Patterns, p2.Patterns, removed),
+		Conditions:     p1.Conditions,
+		Authentication: mergeEntries("authentication", p1.Authentication, p2.Authentication, removed),
+		Metadata:       mergeEntries("metadata", p1.Metadata, p2.Metadata, removed),
+		Authorization:  mergeEntries("authorization", p1.Authorization, p2.Authorization, removed),
+		Removals:       removals,
+	}
+	if len(result.Conditions) == 0 {
+		result.Conditions = p2.Conditions
+	}
+
+	var r1, r2 ResponseSpec
+	if p1.Response != nil {
+		r1 = *p1.Response
+	}
+	if p2.Response != nil {
+		r2 = *p2.Response
+	}
+	response := ResponseSpec{
+		Unauthenticated: r1.Unauthenticated,
+		Unauthorized:    r1.Unauthorized,
+	}
+	if response.Unauthenticated == nil {
+		response.Unauthenticated = r2.Unauthenticated
+	}
+	if response.Unauthorized == nil {
+		response.Unauthorized = r2.Unauthorized
+	}
+	// A response name is shared by both wrappers, as in v1beta1.
+	for name := range r1.Success.Headers {
+		removed["response."+name] = true
+	}
+	for name := range r1.Success.DynamicMetadata {
+		removed["response."+name] = true
+	}
+	response.Success.Headers = mergeEntries("response", r1.Success.Headers, r2.Success.Headers, removed)
+	response.Success.DynamicMetadata = mergeEntries("response", r1.Success.DynamicMetadata, r2.Success.DynamicMetadata, removed)
+	if len(DiffPolicies(ResponseSpec{}, response)) > 0 {
+		result.Response = &response
+	}
+	return result
+}
+
+// SupportedRouteKinds excludes the L4 routes, as does the one of AuthPolicy.
+func (p AuthPolicyV1beta2) SupportedRouteKinds() []RouteKind {
+	return AuthPolicy{}.SupportedRouteKinds()
+}
+
+// mergeEntries keeps the entries of m1, then adds the ones of m2 that aren't in it, nor removed.
+func mergeEntries[V any](section string, m1, m2 map[string]V, removed map[string]bool) map[string]V {
+	if len(m1) == 0 && len(m2) == 0 {
+		return nil
+	}
+	result := make(map[string]V, len(m1)+len(m2))
+	for name, entry := range m1 {
+		result[name] = entry
+	}
+	for name, entry := range m2 {
+		if _, exists := result[name]; exists || removed[fmt.Sprintf("%s.%s", section, name)] {
+			continue
+		}
+		result[name] = entry
+	}
+	return result
+}
+
+// ToV1beta2 converts the policy to the v1beta2 shape. When entries of a section share a name, the first one is kept.
+func (p AuthPolicy) ToV1beta2() AuthPolicyV1beta2 {
+	result := AuthPolicyV1beta2{
+		Patterns:   p.Patterns,
+		Conditions: p.Conditions,
+	}
+	for _, removal := range p.Removals {
+		if strings.HasPrefix(removal, "identity.") {
+			removal = "authentication." + strings.TrimPrefix(removal, "identity.")
+		}
+		result.Removals = append(result.Removals, removal)
+	}
+
+	for _, identity := range p.Identity {
+		if _, exists := result.Authentication[identity.Name]; exists {
+			continue
+		}
+		spec := AuthenticationSpec{
+			CommonEvaluatorSpec:      commonEvaluator(identity.Priority, identity.Metrics, identity.Conditions, identity.Cache),
+			Credentials:              identity.Credentials,
+			ApiKey:                   identity.APIKey,
+			OAuth2TokenIntrospection: identity.OAuth2,
+			KubernetesTokenReview:    identity.KubernetesAuth,
+			X509ClientCertificate:    identity.MTLS,
+			Plain:                    identity.Plain,
+			AnonymousAccess:          identity.Anonymous,
+		}
+		if identity.Oidc != nil {
+			spec.Jwt = &JwtAuthenticationSpec{IssuerUrl: identity.Oidc.Endpoint, TTL: identity.Oidc.TTL}
+		}
+		for _, property := range identity.ExtendedProperties {
+			if spec.Defaults == nil {
+				spec.Defaults = make(map[string]ValueOrSelector)
+			}
+			spec.Defaults[property.Name] = ValueOrSelector{Value: property.Value, Selector: property.ValueFrom.AuthJSON}
+		}
+		if result.Authentication == nil {
+			result.Authentication = make(map[string]AuthenticationSpec)
+		}
+		result.Authentication[identity.Name] = spec
+	}
+
+	for _, metadata := range p.Metadata {
+		if _, exists := result.Metadata[metadata.Name]; exists {
+			continue
+		}
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]MetadataSpec)
+		}
+		result.Metadata[metadata.Name] = MetadataSpec{
+			CommonEvaluatorSpec: commonEvaluator(metadata.Priority, metadata.Metrics, metadata.Conditions, metadata.Cache),
+			Http:                metadata.GenericHTTP,
+			UserInfo:            metadata.UserInfo,
+			Uma:                 metadata.UMA,
+		}
+	}
+
+	for _, authorization := range p.Authorization {
+		if _, exists := result.Authorization[authorization.Name]; exists {
+			continue
+		}
+		spec := AuthorizationSpec{
+			CommonEvaluatorSpec:           commonEvaluator(authorization.Priority, authorization.Metrics, authorization.Conditions, authorization.Cache),
+			Opa:                           authorization.OPA,
+			KubernetesSubjectAccessReview: authorization.KubernetesAuthz,
+		}
+		if authorization.JSON != nil {
+			spec.PatternMatching = &PatternMatchingAuthorizationSpec{Patterns: authorization.JSON.Rules}
+		}
+		if result.Authorization == nil {
+			result.Authorization = make(map[string]AuthorizationSpec)
+		}
+		result.Authorization[authorization.Name] = spec
+	}
+
+	var response ResponseSpec
+	if p.DenyWith != nil {
+		response.Unauthenticated = p.DenyWith.Unauthenticated
+		response.Unauthorized = p.DenyWith.Unauthorized
+	}
+	for _, r := range p.Response {
+		spec := SuccessResponseSpec{
+			CommonEvaluatorSpec: commonEvaluator(r.Priority, r.Metrics, r.Conditions, r.Cache),
+			Key:                 r.WrapperKey,
+			Wristband:           r.Wristband,
+			Json:                r.JSON,
+		}
+		entries := &response.Success.Headers
+		if r.Wrapper == envoyDynamicMetadataWrapper {
+			entries = &response.Success.DynamicMetadata
+		}
+		if _, exists := response.Success.Headers[r.Name]; exists {
+			continue
+		}
+		if _, exists := response.Success.DynamicMetadata[r.Name]; exists {
+			continue
+		}
+		if *entries == nil {
+			*entries = make(map[string]SuccessResponseSpec)
+		}
+		(*entries)[r.Name] = spec
+	}
+	if len(DiffPolicies(ResponseSpec{}, response)) > 0 {
+		result.Response = &response
+	}
+	return result
+}
+
+// ToV1beta1 converts the policy to the v1beta1 shape, the named entries of each section sorted by name. The wrapper
+// of the responses is always explicit, `httpHeader` being the default in v1beta1.
+func (p AuthPolicyV1beta2) ToV1beta1() AuthPolicy {
+	result := AuthPolicy{
+		Patterns:   p.Patterns,
+		Conditions: p.Conditions,
+	}
+	for _, removal := range p.Removals {
+		if strings.HasPrefix(removal, "authentication.") {
+			removal = "identity." + strings.TrimPrefix(removal, "authentication.")
+		}
+		result.Removals = append(result.Removals, removal)
+	}
+
+	for _, name := range sortedKeys(p.Authentication) {
+		spec := p.Authentication[name]
+		identity := &authorino.Identity{
+			Name:           name,
+			Priority:       spec.Priority,
+			Metrics:        spec.Metrics,
+			Conditions:     spec.Conditions,
+			Cache:          spec.Cache,
+			Credentials:    spec.Credentials,
+			APIKey:         spec.ApiKey,
+			OAuth2:         spec.OAuth2TokenIntrospection,
+			KubernetesAuth: spec.KubernetesTokenReview,
+			MTLS:           spec.X509ClientCertificate,
+			Plain:          spec.Plain,
+			Anonymous:      spec.AnonymousAccess,
+		}
+		if spec.Jwt != nil {
+			identity.Oidc = &authorino.Identity_OidcConfig{Endpoint: spec.Jwt.IssuerUrl, TTL: spec.Jwt.TTL}
+		}
+		for _, property := range sortedKeys(spec.Defaults) {
+			value := spec.Defaults[property]
+			identity.ExtendedProperties = append(identity.ExtendedProperties, authorino.JsonProperty{
+				Name:      property,
+				Value:     value.Value,
+				ValueFrom: authorino.ValueFrom{AuthJSON: value.Selector},
+			})
+		}
+		result.Identity = append(result.Identity, identity)
+	}
+
+	for _, name := range sortedKeys(p.Metadata) {
+		spec := p.Metadata[name]
+		result.Metadata = append(result.Metadata, &authorino.Metadata{
+			Name:        name,
+			Priority:    spec.Priority,
+			Metrics:     spec.Metrics,
+			Conditions:  spec.Conditions,
+			Cache:       spec.Cache,
+			GenericHTTP: spec.Http,
+			UserInfo:    spec.UserInfo,
+			UMA:         spec.Uma,
+		})
+	}
+
+	for _, name := range sortedKeys(p.Authorization) {
+		spec := p.Authorization[name]
+		authorization := &authorino.Authorization{
+			Name:            name,
+			Priority:        spec.Priority,
+			Metrics:         spec.Metrics,
+			Conditions:      spec.Conditions,
+			Cache:           spec.Cache,
+			OPA:             spec.Opa,
+			KubernetesAuthz: spec.KubernetesSubjectAccessReview,
+		}
+		if spec.PatternMatching != nil {
+			authorization.JSON = &authorino.Authorization_JSONPatternMatching{Rules: spec.PatternMatching.Patterns}
+		}
+		result.Authorization = append(result.Authorization, authorization)
+	}
+
+	if p.Response == nil {
+		return result
+	}
+	if p.Response.Unauthenticated != nil || p.Response.Unauthorized != nil {
+		result.DenyWith = &authorino.DenyWith{
+			Unauthenticated: p.Response.Unauthenticated,
+			Unauthorized:    p.Response.Unauthorized,
+		}
+	}
+	for _, wrapped := range []struct {
+		wrapper authorino.Response_Wrapper
+		entries map[string]SuccessResponseSpec
+	}{{httpHeaderWrapper, p.Response.Success.Headers}, {envoyDynamicMetadataWrapper, p.Response.Success.DynamicMetadata}} {
+		for _, name := range sortedKeys(wrapped.entries) {
+			spec := wrapped.entries[name]
+			result.Response = append(result.Response, &authorino.Response{
+				Name:       name,
+				Priority:   spec.Priority,
+				Metrics:    spec.Metrics,
+				Conditions: spec.Conditions,
+				Cache:      spec.Cache,
+				Wrapper:    wrapped.wrapper,
+				WrapperKey: spec.Key,
+				Wristband:  spec.Wristband,
+				JSON:       spec.Json,
+			})
+		}
+	}
+	return result
+}
+
+// authPolicyV1beta2Removables are where the named entries of the sections are in the JSON representation, keyed by
+// name. Removals are encoded at the first path of their section.
+var authPolicyV1beta2Removables = []struct {
+	section string
+	path    []string
+}{
+	{"patterns", []string{"patterns"}},
+	{"authentication", []string{"authentication"}},
+	{"metadata", []string{"metadata"}},
+	{"authorization", []string{"authorization"}},
+	{"response", []string{"response", "success", "headers"}},
+	{"response", []string{"response", "success", "dynamicMetadata"}},
+}
+
+func (p *AuthPolicyV1beta2) UnmarshalJSON(data []byte) error {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	var removals []string
+	for _, removable := range authPolicyV1beta2Removables {
+		names, err := stripRemovals(document, removable.path)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			removals = appendUnique(removals, fmt.Sprintf("%s.%s", removable.section, name))
+		}
+	}
+
+	cleaned, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	type authPolicyV1beta2 AuthPolicyV1beta2
+	var policy authPolicyV1beta2
+	if err := json.Unmarshal(cleaned, &policy); err != nil {
+		return err
+	}
+	*p = AuthPolicyV1beta2(policy)
+	sort.Strings(removals)
+	p.Removals = removals
+	return nil
+}
+
+func (p AuthPolicyV1beta2) MarshalJSON() ([]byte, error) {
+	type authPolicyV1beta2 AuthPolicyV1beta2
+	raw, err := json.Marshal(authPolicyV1beta2(p))
+	if err != nil || len(p.Removals) == 0 {
+		return raw, err
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	removed := make(map[string][]string)
+	for _, removal := range p.Removals {
+		parts := strings.SplitN(removal, ".", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid removal %q", removal)
+		}
+		removed[parts[0]] = append(removed[parts[0]], parts[1])
+	}
+	for _, section := range sortedKeys(removed) {
+		encoded := false
+		for _, removable := range authPolicyV1beta2Removables {
+			if removable.section != section {
+				continue
+			}
+			if err := addRemovals(document, removable.path, removed[section]); err != nil {
+				return nil, err
+			}
+			encoded = true
+			break
+		}
+		if !encoded {
+			return nil, fmt.Errorf("invalid removal %q", section+"."+removed[section][0])
+		}
+	}
+	return json.Marshal(document)
+}
+
+// stripRemovals deletes the tombstones from the entries at path within the JSON object, returning their names, sorted.
+// The objects the tombstones leave empty are deleted as well, as they were only there to hold them.
+func stripRemovals(object map[string]json.RawMessage, path []string) ([]string, error) {
+	raw, exists := object[path[0]]
+	if !exists {
+		return nil, nil
+	}
+	var child map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &child); err != nil {
+		return nil, fmt.Errorf("%s: %w", path[0], err)
+	}
+
+	var names []string
+	if len(path) == 1 {
+		for name, entry := range child {
+			if isRemoval(entry) {
+				names = append(names, name)
+				delete(child, name)
+			}
+		}
+		sort.Strings(names)
+	} else {
+		var err error
+		if names, err = stripRemovals(child, path[1:]); err != nil {
+			return nil, fmt.Errorf("%s.%w", path[0], err)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if len(child) == 0 {
+		delete(object, path[0])
+	} else {
+		object[path[0]], _ = json.Marshal(child)
+	}
+	return names, nil
+}
+
+// addRemovals adds tombstones of those names to the entries at path within the JSON object, creating the objects along
+// the path that don't exist.
+func addRemovals(object map[string]json.RawMessage, path []string, names []string) error {
+	child := make(map[string]json.RawMessage)
+	if raw, exists := object[path[0]]; exists && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &child); err != nil {
+			return err
+		}
+	}
+	if len(path) == 1 {
+		for _, name := range names {
+			child[name] = json.RawMessage(fmt.Sprintf(`{%q:true}`, removeMarker))
+		}
+	} else if err := addRemovals(child, path[1:], names); err != nil {
+		return err
+	}
+	object[path[0]], _ = json.Marshal(child)
+	return nil
+}
+
+func commonEvaluator(priority int, metrics bool, conditions []authorino.JSONPattern, cache *authorino.EvaluatorCaching) CommonEvaluatorSpec {
+	return CommonEvaluatorSpec{Priority: priority, Metrics: metrics, Conditions: conditions, Cache: cache}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,96 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"testing"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+	k8s "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestAuthPolicyV1beta2_RoundTrip(t *testing.T) {
+	policy := testAuthPolicySpec2
+	policy.Identity = append([]*authorino.Identity{{
+		Name:        "keycloak",
+		Credentials: authorino.Credentials{In: "authorization_header", KeySelector: "Bearer"},
+		Oidc:        &authorino.Identity_OidcConfig{Endpoint: "https://keycloak.io/realms/toystore", TTL: 60},
+		ExtendedProperties: []authorino.JsonProperty{
+			{Name: "tier", Value: k8s.RawExtension{Raw: []byte(`"free"`)}},
+			{Name: "user", ValueFrom: authorino.ValueFrom{AuthJSON: "auth.identity.sub"}},
+		},
+	}}, policy.Identity...)
+	policy.Authorization = append(policy.Authorization, &authorino.Authorization{
+		Name: "admins",
+		JSON: &authorino.Authorization_JSONPatternMatching{Rules: []authorino.JSONPattern{
+			{JSONPatternRef: authorino.JSONPatternRef{JSONPatternName: "api-version"}},
+		}},
+	})
+	policy.Response = []*authorino.Response{
+		{Name: "x-user", Wrapper: "httpHeader", JSON: &authorino.Response_DynamicJSON{}},
+		{Name: "rate-limit", Wrapper: "envoyDynamicMetadata", WrapperKey: "ext_auth_data"},
+	}
+	policy.Removals = []string{"identity.anonymous", "patterns.api-route", "response.x-debug"}
+
+	converted := policy.ToV1beta2()
+
+	assert.Equal(t, len(converted.Authentication), 2)
+	assert.Equal(t, converted.Authentication["keycloak"].Jwt.IssuerUrl, "https://keycloak.io/realms/toystore")
+	assert.Equal(t, converted.Authentication["keycloak"].Defaults["user"].Selector, "auth.identity.sub")
+	assert.Check(t, converted.Authentication["friends"].ApiKey != nil)
+	assert.Equal(t, len(converted.Authorization["admins"].PatternMatching.Patterns), 1)
+	assert.Equal(t, int(converted.Response.Unauthorized.Code), 302)
+	assert.Equal(t, converted.Response.Success.DynamicMetadata["rate-limit"].Key, "ext_auth_data")
+	assert.Check(t, converted.Response.Success.Headers["x-user"].Json != nil)
+	assert.DeepEqual(t, converted.Removals, []string{"authentication.anonymous", "patterns.api-route", "response.x-debug"})
+
+	data, err := json.Marshal(converted)
+	assert.NilError(t, err)
+	var unmarshalled AuthPolicyV1beta2
+	assert.NilError(t, json.Unmarshal(data, &unmarshalled))
+	assert.DeepEqual(t, unmarshalled.Removals, converted.Removals)
+
+	back := unmarshalled.ToV1beta1()
+	assert.Equal(t, len(DiffPolicies(policy, back)), 0, "%v", DiffPolicies(policy, back))
+	assert.DeepEqual(t, back.Removals, policy.Removals)
+
+	// Tombstones alone leave no section behind.
+	data, err = json.Marshal(AuthPolicyV1beta2{Removals: []string{"response.x-debug"}})
+	assert.NilError(t, err)
+	unmarshalled = AuthPolicyV1beta2{}
+	assert.NilError(t, json.Unmarshal(data, &unmarshalled))
+	assert.DeepEqual(t, unmarshalled, AuthPolicyV1beta2{Removals: []string{"response.x-debug"}})
+}
+
+func TestAuthPolicyV1beta2_Merge(t *testing.T) {
+	gatewayPolicy := testAuthPolicySpec1
+	gatewayPolicy.Response = []*authorino.Response{{Name: "x-user", Wrapper: "httpHeader"}}
+	routePolicy := testAuthPolicySpec2
+	routePolicy.Response = []*authorino.Response{{Name: "x-user", Wrapper: "envoyDynamicMetadata"}}
+	routePolicy.Metadata = []*authorino.Metadata{{Name: "user-info", UserInfo: &authorino.Metadata_UserInfo{IdentitySource: "friends"}}}
+	routePolicy.Removals = []string{"patterns.api-route"}
+
+	v1 := NewGatewayClass[AuthPolicy]("gwc")
+	gw1 := v1.CreateGateway("gw")
+	gw1.AddPolicy(PolicySpec[AuthPolicy]{name: "gw-policy", overrides: gatewayPolicy})
+	route1 := gw1.CreateRoute("route")
+	route1.AddPolicy(PolicySpec[AuthPolicy]{name: "route-policy", defaults: routePolicy})
+
+	v2 := NewGatewayClass[AuthPolicyV1beta2]("gwc")
+	gw2 := v2.CreateGateway("gw")
+	gw2.AddPolicy(PolicySpec[AuthPolicyV1beta2]{name: "gw-policy", overrides: gatewayPolicy.ToV1beta2()})
+	route2 := gw2.CreateRoute("route")
+	route2.AddPolicy(PolicySpec[AuthPolicyV1beta2]{name: "route-policy", defaults: routePolicy.ToV1beta2()})
+	tcp := gw2.CreateTcpRoute("tcp")
+
+	expected := route1.MergedPolicies(AuthPolicyMerger)
+	effective := route2.MergedPolicies(AuthPolicyV1beta2Merger)
+
+	assert.Equal(t, len(DiffPolicies(expected, effective.ToV1beta1())), 0, "%v", DiffPolicies(expected, effective.ToV1beta1()))
+	assert.Check(t, effective.Authentication["friends"].AnonymousAccess != nil)
+	assert.Check(t, effective.Response.Success.Headers["x-user"].Key == "")
+	assert.Equal(t, len(effective.Response.Success.DynamicMetadata), 0)
+	assert.Equal(t, effective.Patterns["api-version"][0].Value, "^v[0-9]+")
+	assert.Equal(t, len(tcp.MergedPolicies(AuthPolicyV1beta2Merger).Authentication), 0)
+}
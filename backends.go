@@ -0,0 +1,164 @@
+package gw_policies_playground
+
+import "sort"
+
+// Service is a backend of the routes of a class, as in the mesh (GAMMA) flavour of policy attachment. It's the most
+// specific level of the hierarchy: its policies merge below the ones of the route the traffic goes through, and so the
+// effective policy of a backend shared by many routes may depend on the route.
+type Service[T Policy] struct {
+	parent   *GatewayClass[T]
+	name     string
+	policies []PolicySpec[T]
+}
+
+// RouteRule is a rule of an HttpRoute, forwarding the traffic it matches to its backends.
+type RouteRule[T Policy] struct {
+	Name        string
+	BackendRefs []*Service[T]
+}
+
+// BackendConflict is a route to a backend through which the effective policy of the backend isn't the one reported
+// for it, along with the fields that differ.
+type BackendConflict struct {
+	Route   RouteRef
+	Changes []FieldDiff
+}
+
+// CreateService adds a service to the class, unless it has one of that name already, which is returned then: routes
+// refer to services by name.
+func (gwc *GatewayClass[T]) CreateService(name string) *Service[T] {
+	gwc.lock.Lock()
+	defer gwc.lock.Unlock()
+
+	for s := range gwc.services {
+		if s.name == name {
+			return s
+		}
+	}
+	s := &Service[T]{
+		parent: gwc,
+		name:   name,
+	}
+	gwc.services[s] = sentinel
+	return s
+}
+
+// Service looks up a service of the class by name.
+func (gwc *GatewayClass[T]) Service(name string) (*Service[T], bool) {
+	gwc.lock.RLock()
+	defer gwc.lock.RUnlock()
+
+	for s := range gwc.services {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Service[T]) AddPolicy(policy PolicySpec[T]) {
+	s.parent.lock.Lock()
+	defer s.parent.lock.Unlock()
+
+	s.policies = append(s.policies, policy)
+}
+
+func (s *Service[T]) RemovePolicy(name string) bool {
+	s.parent.lock.Lock()
+	defer s.parent.lock.Unlock()
+
+	var removed bool
+	s.policies, removed = removePolicy(s.policies, name)
+	return removed
+}
+
+// AddRule adds a rule forwarding to the backends, which must be services of the route's class.
+func (r *HttpRoute[T]) AddRule(name string, backendRefs ...*Service[T]) {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	r.rules = append(r.rules, RouteRule[T]{Name: name, BackendRefs: backendRefs})
+}
+
+func (r *HttpRoute[T]) Rules() []RouteRule[T] {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	return append([]RouteRule[T](nil), r.rules...)
+}
+
+// BackendMergedPolicies is the effective policy of the traffic the route forwards to the backend, whether the route
+// refers to it or not.
+func (r *HttpRoute[T]) BackendMergedPolicies(backend *Service[T], merger func(T, T) T) T {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	return foldContributions(r.backendContributions(backend), merger)
+}
+
+// backendContributions expects the caller to hold the tree's lock.
+func (r *HttpRoute[T]) backendContributions(backend *Service[T]) []policyContribution[T] {
+	if !supportsRouteKind[T](HTTPRouteKind) {
+		return nil
+	}
+	contributions := addContributions(nil, backend, backend.policies)
+	contributions = addContributions(contributions, r, r.policies)
+	contributions = addContributions(contributions, r.parent, r.parent.policies)
+	return inheritedContributions[T](backend, addContributions(contributions, r.parent.parent, r.parent.parent.policies))
+}
+
+// MergedPolicies is the effective policy of the backend through the first of the routes referring to it, by gateway
+// and route name, so that it's the same whatever the order the routes were created in. The other routes through which
+// the backend's effective policy differs are reported as conflicts. A backend no route refers to only has its own
+// policies.
+func (s *Service[T]) MergedPolicies(merger func(T, T) T) (T, []BackendConflict) {
+	s.parent.lock.RLock()
+	defer s.parent.lock.RUnlock()
+
+	routes := s.routes()
+	if len(routes) == 0 {
+		return foldContributions(addContributions(nil, s, s.policies), merger), nil
+	}
+	effective := foldContributions(routes[0].backendContributions(s), merger)
+	var conflicts []BackendConflict
+	for _, route := range routes[1:] {
+		changes := DiffPolicies(effective, foldContributions(route.backendContributions(s), merger))
+		if len(changes) > 0 {
+			conflicts = append(conflicts, BackendConflict{
+				Route:   routeRefOf[T](route),
+				Changes: changes,
+			})
+		}
+	}
+	return effective, conflicts
+}
+
+// routes expects the caller to hold the tree's lock. They're sorted by gateway and route name.
+func (s *Service[T]) routes() []*HttpRoute[T] {
+	var routes []*HttpRoute[T]
+	for gw := range s.parent.gateways {
+		for route := range gw.routes {
+			if route.refersTo(s) {
+				routes = append(routes, route)
+			}
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].parent.name != routes[j].parent.name {
+			return routes[i].parent.name < routes[j].parent.name
+		}
+		return routes[i].name < routes[j].name
+	})
+	return routes
+}
+
+func (r *HttpRoute[T]) refersTo(backend *Service[T]) bool {
+	for _, rule := range r.rules {
+		for _, ref := range rule.BackendRefs {
+			if ref == backend {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestBackendMergedPolicies(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+	backend := gwc.CreateService("toystore")
+	route.AddRule("all", backend)
+
+	gw.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw", overrides: testRateLimitPolicySpec1})
+	route.AddPolicy(PolicySpec[RateLimitPolicy]{name: "route", defaults: RateLimitPolicy{Limits: map[string]Limit{
+		"per-user": {Rates: []Rate{{Limit: 1000, Duration: 1, Unit: HourTimeUnit}}},
+	}}})
+	backend.AddPolicy(PolicySpec[RateLimitPolicy]{name: "backend", defaults: testRateLimitPolicySpec2})
+
+	result := route.BackendMergedPolicies(backend, RateLimitPolicyMerger)
+
+	assert.Equal(t, len(result.Limits), 2)
+	assert.Equal(t, len(result.Limits["subnet"].When), 1)
+	assert.Equal(t, result.Limits["per-user"].Rates[0].Limit, 100)
+	assert.Equal(t, route.MergedPolicies(RateLimitPolicyMerger).Limits["per-user"].Rates[0].Limit, 1000)
+
+	found, exists := gwc.Service("toystore")
+	assert.Check(t, exists)
+	assert.Equal(t, found, backend)
+	assert.Equal(t, route.Rules()[0].BackendRefs[0], backend)
+	assert.Equal(t, gwc.CreateService("toystore"), backend)
+	assert.Equal(t, len(gwc.services), 1)
+}
+
+func TestBackendMergedPolicies_SharedBackend(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw1 := gwc.CreateGateway("gw1")
+	gw2 := gwc.CreateGateway("gw2")
+	backend := gwc.CreateService("toystore")
+	other := gwc.CreateService("other")
+	for _, route := range []*HttpRoute[RateLimitPolicy]{gw2.CreateRoute("api"), gw1.CreateRoute("web"), gw1.CreateRoute("api")} {
+		route.AddRule("all", backend, other)
+	}
+	backend.AddPolicy(PolicySpec[RateLimitPolicy]{name: "backend", defaults: testRateLimitPolicySpec2})
+
+	result, conflicts := backend.MergedPolicies(RateLimitPolicyMerger)
+
+	assert.Equal(t, len(result.Limits), 2)
+	assert.Equal(t, len(conflicts), 0)
+
+	gw2.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw2", overrides: testRateLimitPolicySpec1})
+	result, conflicts = backend.MergedPolicies(RateLimitPolicyMerger)
+
+	assert.Equal(t, len(result.Limits["subnet"].When), 2)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].Route, RouteRef{Gateway: "gw2", Route: "api"})
+	assert.Equal(t, conflicts[0].Changes[0].Path, "limits[subnet].when[1]")
+
+	unreferenced := gwc.CreateService("unreferenced")
+	unreferenced.AddPolicy(PolicySpec[RateLimitPolicy]{name: "unreferenced", defaults: testRateLimitPolicySpec1})
+	result, conflicts = unreferenced.MergedPolicies(RateLimitPolicyMerger)
+
+	assert.Equal(t, len(result.Limits), 1)
+	assert.Equal(t, len(conflicts), 0)
+}
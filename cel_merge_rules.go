@@ -0,0 +1,212 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// CelMergeRules merges UnstructuredPolicy documents with a CEL expression per field. An expression sees the `parent`
+// and `child` documents, as well as the `level` the policy being merged in is attached at and its `kind`, i.e.
+// `default` or `override`, and evaluates to the merged value of the field, `null` leaving it out. Besides the
+// standard CEL functions, `min` and `max` are available, e.g. `min(parent.limit, child.limit)`.
+//
+// A rule is only evaluated if the field is set in either document, so it should check with `has()` before using one
+// that might not be. The fields without a rule are merged recursively, the child winning over the parent for
+// defaults, and the parent winning for overrides.
+type CelMergeRules struct {
+	rules []celMergeRule
+}
+
+type celMergeRule struct {
+	path       []string
+	expression string
+	program    cel.Program
+}
+
+// NewCelMergeRules compiles the rules, keyed by the dot separated path of the field they merge, e.g. `limits.toys`.
+func NewCelMergeRules(rules map[string]string) (*CelMergeRules, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("parent", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("child", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("level", cel.StringType),
+		cel.Variable("kind", cel.StringType),
+		celNumericFunction("min", true),
+		celNumericFunction("max", false),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(rules))
+	for path := range rules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	compiled := &CelMergeRules{}
+	for _, path := range paths {
+		ast, issues := env.Compile(rules[path])
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("rule for %s: %w", path, issues.Err())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("rule for %s: %w", path, err)
+		}
+		compiled.rules = append(compiled.rules, celMergeRule{
+			path:       strings.Split(path, "."),
+			expression: rules[path],
+			program:    program,
+		})
+	}
+	return compiled, nil
+}
+
+// UnmarshalJSON reads the rules as an object of expressions keyed by path, so that they can be part of a scenario.
+func (r *CelMergeRules) UnmarshalJSON(data []byte) error {
+	var rules map[string]string
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	compiled, err := NewCelMergeRules(rules)
+	if err != nil {
+		return err
+	}
+	*r = *compiled
+	return nil
+}
+
+func (r *CelMergeRules) MarshalJSON() ([]byte, error) {
+	rules := make(map[string]string, len(r.rules))
+	for _, rule := range r.rules {
+		rules[strings.Join(rule.path, ".")] = rule.expression
+	}
+	return json.Marshal(rules)
+}
+
+// MergedPolicies merges the policies of the route with the rules, failing if any rule fails to evaluate.
+func (r *CelMergeRules) MergedPolicies(route *HttpRoute[UnstructuredPolicy]) (UnstructuredPolicy, error) {
+	return route.MergedPoliciesInContext(r.Merge)
+}
+
+// Merge is the merger to use with MergedPoliciesInContext, failing if a rule fails to evaluate.
+func (r *CelMergeRules) Merge(parent, child UnstructuredPolicy, context MergeContext) (UnstructuredPolicy, error) {
+	kind := "default"
+	if context.Override {
+		kind = "override"
+	}
+	result := UnstructuredPolicy(mergeUnstructured(parent, child, context.Override))
+
+	for _, rule := range r.rules {
+		_, inParent := lookupPath(parent, rule.path)
+		_, inChild := lookupPath(child, rule.path)
+		if !inParent && !inChild {
+			continue
+		}
+		out, _, err := rule.program.Eval(map[string]interface{}{
+			"parent": map[string]interface{}(parent),
+			"child":  map[string]interface{}(child),
+			"level":  context.Level,
+			"kind":   kind,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rule for %s: %w", strings.Join(rule.path, "."), err)
+		}
+		value, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+		if err != nil {
+			return nil, fmt.Errorf("rule for %s: %w", strings.Join(rule.path, "."), err)
+		}
+		result = setPath(result, rule.path, value.(*structpb.Value).AsInterface())
+	}
+	return result, nil
+}
+
+// mergeUnstructured merges the objects recursively, any other value of the child replacing the one of the parent,
+// unless parentWins.
+func mergeUnstructured(parent, child map[string]interface{}, parentWins bool) map[string]interface{} {
+	if parent == nil && child == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(parent)+len(child))
+	for key, value := range parent {
+		result[key] = value
+	}
+	for key, value := range child {
+		existing, exists := result[key]
+		if !exists {
+			result[key] = value
+			continue
+		}
+		existingObject, existingIsObject := existing.(map[string]interface{})
+		object, isObject := value.(map[string]interface{})
+		switch {
+		case existingIsObject && isObject:
+			result[key] = mergeUnstructured(existingObject, object, parentWins)
+		case !parentWins:
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func lookupPath(document map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = document
+	for _, key := range path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// setPath sets, or removes if nil, the value at path, copying the objects on the way rather than mutating them.
+func setPath(document map[string]interface{}, path []string, value interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(document)+1)
+	for key, existing := range document {
+		result[key] = existing
+	}
+	if len(path) == 1 {
+		if value == nil {
+			delete(result, path[0])
+		} else {
+			result[path[0]] = value
+		}
+		return result
+	}
+	object, _ := result[path[0]].(map[string]interface{})
+	result[path[0]] = setPath(object, path[1:], value)
+	return result
+}
+
+// celNumericFunction declares a function returning the lowest of two numbers of the same type, the highest if not
+// lowest.
+func celNumericFunction(name string, lowest bool) cel.EnvOption {
+	return cel.Function(name,
+		cel.Overload(name+"_double_double", []*cel.Type{cel.DoubleType, cel.DoubleType}, cel.DoubleType,
+			cel.BinaryBinding(func(a, b ref.Val) ref.Val {
+				if (a.(types.Double) < b.(types.Double)) == lowest {
+					return a
+				}
+				return b
+			})),
+		cel.Overload(name+"_int_int", []*cel.Type{cel.IntType, cel.IntType}, cel.IntType,
+			cel.BinaryBinding(func(a, b ref.Val) ref.Val {
+				if (a.(types.Int) < b.(types.Int)) == lowest {
+					return a
+				}
+				return b
+			})),
+	)
+}
@@ -0,0 +1,68 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func testCelHierarchy() *HttpRoute[UnstructuredPolicy] {
+	gwc := NewGatewayClass[UnstructuredPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	gwc.AddPolicy(NewPolicySpec("class", UnstructuredPolicy{
+		"limit": 100.0,
+		"tags":  map[string]interface{}{"team": "ops", "tier": "free"},
+		"trace": []interface{}{},
+	}, nil))
+	gw.AddPolicy(NewPolicySpec("gw", nil, UnstructuredPolicy{
+		"limit": 60.0,
+		"trace": []interface{}{},
+	}))
+	route.AddPolicy(NewPolicySpec("route", UnstructuredPolicy{
+		"limit": 80.0,
+		"tags":  map[string]interface{}{"app": "toys", "tier": "paid"},
+		"trace": []interface{}{},
+	}, nil))
+	return route
+}
+
+func TestCelMergeRules(t *testing.T) {
+	var rules CelMergeRules
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"limit": "has(parent.limit) && has(child.limit) ? min(parent.limit, child.limit) : (has(parent.limit) ? parent.limit : child.limit)",
+		"trace": "(has(parent.trace) ? parent.trace : []) + (has(child.trace) ? child.trace : []) + [level + ':' + kind]"
+	}`), &rules))
+
+	policy, err := rules.MergedPolicies(testCelHierarchy())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policy, UnstructuredPolicy{
+		"limit": 60.0,
+		"tags":  map[string]interface{}{"team": "ops", "app": "toys", "tier": "paid"},
+		"trace": []interface{}{"GatewayClass:default", "HTTPRoute:default", "Gateway:override"},
+	})
+
+	raw, err := json.Marshal(&rules)
+	assert.NilError(t, err)
+	assert.Check(t, len(raw) > 2)
+}
+
+func TestCelMergeRules_Errors(t *testing.T) {
+	_, err := NewCelMergeRules(map[string]string{"limit": "min(parent.limit,"})
+	assert.ErrorContains(t, err, "rule for limit:")
+
+	rules, err := NewCelMergeRules(map[string]string{"tags.tier": "parent.tags.tier"})
+	assert.NilError(t, err)
+	_, err = rules.MergedPolicies(testCelHierarchy())
+	assert.ErrorContains(t, err, "rule for tags.tier: no such key: tags")
+	_, err = rules.Merge(UnstructuredPolicy{}, UnstructuredPolicy{"tags": map[string]interface{}{"tier": "gold"}}, MergeContext{Level: "Gateway"})
+	assert.ErrorContains(t, err, "rule for tags.tier: no such key: tags")
+
+	rules, err = NewCelMergeRules(map[string]string{"tags.tier": "null"})
+	assert.NilError(t, err)
+	policy, err := rules.MergedPolicies(testCelHierarchy())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policy["tags"], map[string]interface{}{"team": "ops", "app": "toys"})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	playground "gw-policies-playground"
+)
+
+func describeCommand(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("expected a hierarchy file, a gateway and optionally one of its routes")
+	}
+	gwc, err := loadHierarchy(args[0])
+	if err != nil {
+		return err
+	}
+	gw, exists := gwc.Gateway(args[1])
+	if !exists {
+		return fmt.Errorf("no gateway %q", args[1])
+	}
+	if len(args) == 2 {
+		fmt.Fprintf(os.Stdout, "Gateway: %s\n", args[1])
+		return printAffectingPolicies(os.Stdout, gw.AffectingPolicies(playground.AuthPolicyMerger))
+	}
+	route, exists := gw.Route(args[2])
+	if !exists {
+		return fmt.Errorf("no route %q on gateway %q", args[2], args[1])
+	}
+	fmt.Fprintf(os.Stdout, "HTTPRoute: %s/%s\n", args[1], args[2])
+	return printAffectingPolicies(os.Stdout, route.AffectingPolicies(playground.AuthPolicyMerger))
+}
+
+func printAffectingPolicies(w io.Writer, policies []playground.AffectingPolicy) error {
+	if len(policies) == 0 {
+		_, err := fmt.Fprintln(w, "AffectingPolicies: <none>")
+		return err
+	}
+	fmt.Fprintln(w, "AffectingPolicies:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  NAME\tTARGET\tCLASS\tATTACHMENT\tPARTICIPATION\tOVERRIDDEN FIELDS")
+	for _, policy := range policies {
+		attachment := "Attached"
+		if policy.Inherited {
+			attachment = "Ancestor"
+		}
+		overridden := "-"
+		if len(policy.Overridden) > 0 {
+			overridden = strings.Join(policy.Overridden, ", ")
+		}
+		fmt.Fprintf(tw, "  %s\t%s/%s\t%s\t%s\t%s\t%s\n", policy.Name, policy.TargetKind, policy.TargetName, policy.Class, attachment, policy.Participation, overridden)
+	}
+	return tw.Flush()
+}
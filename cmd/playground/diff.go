@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	playground "gw-policies-playground"
+)
+
+func diffCommand(args []string) error {
+	if len(args) != 2 {
+		return errors.New("expected two hierarchy files, before and after")
+	}
+	before, err := loadHierarchy(args[0])
+	if err != nil {
+		return err
+	}
+	after, err := loadHierarchy(args[1])
+	if err != nil {
+		return err
+	}
+	printDiff(os.Stdout, playground.DiffEffectivePolicies(before, after, playground.AuthPolicyMerger))
+	return nil
+}
+
+func printDiff(w io.Writer, diff playground.HierarchyDiff) {
+	for _, route := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n", route.RouteRef)
+		for _, change := range route.Changes {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", change.Path, change.Before, change.After)
+		}
+	}
+	for _, route := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", route)
+	}
+	for _, route := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", route)
+	}
+	for _, route := range diff.Unchanged {
+		fmt.Fprintf(w, "= %s\n", route)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	playground "gw-policies-playground"
+)
+
+// loadHierarchy reads a GatewayClass tree of AuthPolicy, as described by the JSON snapshots of the playground.
+func loadHierarchy(path string) (*playground.GatewayClass[playground.AuthPolicy], error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	gwc, err := playground.ImportSnapshot[playground.AuthPolicy](raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return gwc, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: playground <command> [arguments]
+
+commands:
+  diff <before.json> <after.json>            effective AuthPolicy changes, per route, between two hierarchies
+  describe <hierarchy.json> <gateway> [route] policies affecting a gateway or one of its routes
+  serve <address> [hierarchy.json]           HTTP API over a hierarchy, empty unless loaded from a file
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "diff":
+		err = diffCommand(os.Args[2:])
+	case "describe":
+		err = describeCommand(os.Args[2:])
+	case "serve":
+		err = serveCommand(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	playground "gw-policies-playground"
+)
+
+func serveCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("expected an address to listen on and optionally a hierarchy file to start from")
+	}
+	gwc := playground.NewGatewayClass[playground.AuthPolicy]("playground")
+	hierarchy := &gwc
+	if len(args) == 2 {
+		var err error
+		if hierarchy, err = loadHierarchy(args[1]); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Serving on %s\n", args[0])
+	return http.ListenAndServe(args[0], playground.NewServer(hierarchy, playground.AuthPolicyMerger))
+}
@@ -0,0 +1,206 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a guardrail a cluster operator attaches to a GatewayClass or a Gateway: the policies attached beneath
+// it, and the effective policy of the routes beneath it, must pass its check. A rejecting constraint has AdmitPolicy
+// refuse the policies that don't.
+type Constraint[T Policy] struct {
+	name   string
+	check  func(T) []string
+	reject bool
+}
+
+// NewConstraint creates a constraint whose check returns a message per violation, none if the policy satisfies it.
+func NewConstraint[T Policy](name string, check func(T) []string, reject bool) Constraint[T] {
+	return Constraint[T]{
+		name:   name,
+		check:  check,
+		reject: reject,
+	}
+}
+
+type ConstraintViolation struct {
+	Constraint string
+
+	// Policy is the name of the PolicySpec violating the constraint, whose overrides do if Override is set, its
+	// defaults otherwise. It's empty when Route is set instead, as the effective policy of that route violates it.
+	// RouteKind is empty for HTTPRoutes, as is the Kind of a RouteRef.
+	Policy    string
+	Override  bool
+	Route     string
+	RouteKind RouteKind
+
+	Message string
+}
+
+func (v ConstraintViolation) Error() string {
+	switch {
+	case v.Route != "" && v.RouteKind != "":
+		return fmt.Sprintf("%s: effective policy of %s %s: %s", v.Constraint, v.RouteKind, v.Route, v.Message)
+	case v.Route != "":
+		return fmt.Sprintf("%s: effective policy of route %s: %s", v.Constraint, v.Route, v.Message)
+	case v.Override:
+		return fmt.Sprintf("%s: overrides of %s: %s", v.Constraint, v.Policy, v.Message)
+	default:
+		return fmt.Sprintf("%s: defaults of %s: %s", v.Constraint, v.Policy, v.Message)
+	}
+}
+
+type ConstraintViolations []ConstraintViolation
+
+func (v ConstraintViolations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, violation := range v {
+		messages = append(messages, violation.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (gwc *GatewayClass[T]) AddConstraint(constraint Constraint[T]) {
+	gwc.lock.Lock()
+	defer gwc.lock.Unlock()
+
+	gwc.constraints = append(gwc.constraints, constraint)
+}
+
+func (gw *Gateway[T]) AddConstraint(constraint Constraint[T]) {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	gw.constraints = append(gw.constraints, constraint)
+}
+
+// Violations lists how the policies attached to the route and its gateway, and the effective policy of the route,
+// violate the constraints above them. The effective policy is the zero T if the policy type doesn't support the kind of
+// the route.
+func (r *routeBase[T]) Violations(merger func(T, T) T) ConstraintViolations {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	gw := r.parent
+	violations := checkPolicies(gw.parent.constraints, gw.policies, false)
+	violations = append(violations, checkPolicies(gw.constraints, r.policies, false)...)
+	violations = append(violations, checkPolicies(gw.parent.constraints, r.policies, false)...)
+	return append(violations, effectiveViolations(r.node, merger, false)...)
+}
+
+// AdmitPolicy attaches the policy to the gateway, unless it violates a rejecting constraint of the class, or makes the
+// effective policy, as merged by merger, of any of the routes of the gateway, of all kinds, violate one. The violations
+// are returned as ConstraintViolations then.
+func (gw *Gateway[T]) AdmitPolicy(policy PolicySpec[T], merger func(T, T) T) error {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	violations := checkPolicies(gw.parent.constraints, []PolicySpec[T]{policy}, true)
+	before := gw.routesEffectiveViolations(merger)
+	gw.policies = append(gw.policies, policy)
+	violations = append(violations, introduced(before, gw.routesEffectiveViolations(merger))...)
+	if len(violations) > 0 {
+		gw.policies = gw.policies[:len(gw.policies)-1]
+		return violations
+	}
+	gw.generation++
+	gw.parent.notify(gw, Mutation{Kind: PolicyAttached, TargetKind: "Gateway", TargetName: gw.name, Policy: policy.name})
+	return nil
+}
+
+// AdmitPolicy attaches the policy to the route, unless it violates a rejecting constraint of the gateway or the class,
+// or makes the effective policy of the route, as merged by merger, violate one. The violations are returned as
+// ConstraintViolations then.
+func (r *routeBase[T]) AdmitPolicy(policy PolicySpec[T], merger func(T, T) T) error {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	gw := r.parent
+	violations := checkPolicies(gw.constraints, []PolicySpec[T]{policy}, true)
+	violations = append(violations, checkPolicies(gw.parent.constraints, []PolicySpec[T]{policy}, true)...)
+	before := effectiveViolations(r.node, merger, true)
+	r.policies = append(r.policies, policy)
+	violations = append(violations, introduced(before, effectiveViolations(r.node, merger, true))...)
+	if len(violations) > 0 {
+		r.policies = r.policies[:len(r.policies)-1]
+		return violations
+	}
+	gw.parent.notify(r.node, Mutation{Kind: PolicyAttached, TargetKind: string(r.node.kind()), TargetName: r.name, Gateway: gw.name, Policy: policy.name})
+	return nil
+}
+
+// introduced returns the violations of after that aren't in before, i.e. the ones an admitted policy would introduce.
+func introduced(before, after ConstraintViolations) ConstraintViolations {
+	var violations ConstraintViolations
+	for _, violation := range after {
+		existing := false
+		for _, previous := range before {
+			if previous == violation {
+				existing = true
+				break
+			}
+		}
+		if !existing {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// effectiveViolations expects the caller to hold the tree's lock.
+func effectiveViolations[T Policy](route routeNode[T], merger func(T, T) T, rejectingOnly bool) ConstraintViolations {
+	effective := foldContributions(applicableContributions(route), merger)
+	ref := routeRefOf(route)
+	gw := route.gateway()
+	var violations ConstraintViolations
+	for _, constraints := range [][]Constraint[T]{gw.constraints, gw.parent.constraints} {
+		for _, constraint := range constraints {
+			if rejectingOnly && !constraint.reject {
+				continue
+			}
+			for _, message := range constraint.check(effective) {
+				violations = append(violations, ConstraintViolation{Constraint: constraint.name, Route: ref.Route, RouteKind: ref.Kind, Message: message})
+			}
+		}
+	}
+	return violations
+}
+
+// routesEffectiveViolations expects the caller to hold the tree's lock.
+func (gw *Gateway[T]) routesEffectiveViolations(merger func(T, T) T) ConstraintViolations {
+	var violations ConstraintViolations
+	for _, route := range routesBeneath[T](gw) {
+		violations = append(violations, effectiveViolations(route, merger, true)...)
+	}
+	return violations
+}
+
+// checkPolicies checks the defaults and overrides of the policies that aren't empty.
+func checkPolicies[T Policy](constraints []Constraint[T], policies []PolicySpec[T], rejectingOnly bool) ConstraintViolations {
+	var empty T
+	var violations ConstraintViolations
+	for _, constraint := range constraints {
+		if rejectingOnly && !constraint.reject {
+			continue
+		}
+		for _, policy := range policies {
+			for _, half := range []struct {
+				override bool
+				policy   T
+			}{{false, policy.defaults}, {true, policy.overrides}} {
+				if len(DiffPolicies(empty, half.policy)) == 0 {
+					continue
+				}
+				for _, message := range constraint.check(half.policy) {
+					violations = append(violations, ConstraintViolation{
+						Constraint: constraint.name,
+						Policy:     policy.name,
+						Override:   half.override,
+						Message:    message,
+					})
+				}
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,85 @@
+package gw_policies_playground
+
+import (
+	"errors"
+	"testing"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+func TestConstraints_Violations(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	gwc.AddConstraint(NoAnonymousIdentityConstraint(false))
+	gw.AddConstraint(DenyWithCodesConstraint(false, 401, 403))
+
+	gw.AddPolicy(NewPolicySpec("gw", testAuthPolicySpec1, AuthPolicy{}))
+	route.AddPolicy(NewPolicySpec("route", AuthPolicy{}, testAuthPolicySpec2))
+
+	assert.DeepEqual(t, route.Violations(AuthPolicyMerger), ConstraintViolations{
+		{Constraint: "no-anonymous-identity", Policy: "gw", Message: "identity friends is anonymous"},
+		{Constraint: "deny-with-codes", Policy: "route", Override: true, Message: "unauthorized requests are denied with 302, not one of [401 403]"},
+		{Constraint: "deny-with-codes", Route: "route", Message: "unauthorized requests are denied with 302, not one of [401 403]"},
+	})
+}
+
+func TestConstraints_AdmitPolicy(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	issuer := "https://sso.example.com"
+	gwc.AddConstraint(OidcIssuerConstraint(issuer, true))
+	gw.AddConstraint(DenyWithCodesConstraint(false, 401, 403))
+
+	err := route.AdmitPolicy(NewPolicySpec("apikey", testAuthPolicySpec2, AuthPolicy{}), AuthPolicyMerger)
+	var violations ConstraintViolations
+	assert.Check(t, errors.As(err, &violations))
+	assert.Equal(t, len(violations), 2)
+	assert.Equal(t, err.Error(), "oidc-issuer: defaults of apikey: identity friends isn't OIDC; "+
+		"oidc-issuer: effective policy of route route: identity friends isn't OIDC")
+	assert.Equal(t, len(route.policies), 0)
+
+	sso := AuthPolicy{
+		Identity: []*authorino.Identity{{Name: "sso", Oidc: &authorino.Identity_OidcConfig{Endpoint: issuer}}},
+		DenyWith: testAuthPolicySpec2.DenyWith,
+	}
+	assert.NilError(t, route.AdmitPolicy(NewPolicySpec("sso", sso, AuthPolicy{}), AuthPolicyMerger))
+	assert.Equal(t, len(route.policies), 1)
+	assert.Equal(t, len(route.Violations(AuthPolicyMerger)), 2)
+
+	// The gateway's default would add an identity from another issuer to the route.
+	err = gw.AdmitPolicy(NewPolicySpec("gw", AuthPolicy{
+		Identity: []*authorino.Identity{{Name: "guests", Oidc: &authorino.Identity_OidcConfig{Endpoint: "https://other.example.com"}}},
+	}, AuthPolicy{}), AuthPolicyMerger)
+	assert.ErrorContains(t, err, "identity guests trusts issuer https://other.example.com, not https://sso.example.com")
+	assert.Equal(t, len(gw.policies), 0)
+	assert.Equal(t, gw.generation, uint64(0))
+}
+
+func TestConstraints_RouteKinds(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	catalog := gw.CreateGrpcRoute("catalog")
+	gw.CreateTcpRoute("db")
+
+	// Only the effective policy of the routes is checked against the constraints of the gateway.
+	gw.AddConstraint(OidcIssuerConstraint("https://sso.example.com", true))
+
+	err := gw.AdmitPolicy(NewPolicySpec("gw", testAuthPolicySpec2, AuthPolicy{}), AuthPolicyMerger)
+	assert.Equal(t, err.Error(), "oidc-issuer: effective policy of GRPCRoute catalog: identity friends isn't OIDC")
+	assert.Equal(t, len(gw.policies), 0)
+
+	err = catalog.AdmitPolicy(NewPolicySpec("catalog", testAuthPolicySpec2, AuthPolicy{}), AuthPolicyMerger)
+	assert.ErrorContains(t, err, "oidc-issuer: defaults of catalog: identity friends isn't OIDC")
+	assert.Equal(t, len(catalog.policies), 0)
+
+	gw.AddPolicy(NewPolicySpec("gw", testAuthPolicySpec2, AuthPolicy{}))
+	assert.DeepEqual(t, catalog.Violations(AuthPolicyMerger), ConstraintViolations{
+		{Constraint: "oidc-issuer", Route: "catalog", RouteKind: GRPCRouteKind, Message: "identity friends isn't OIDC"},
+	})
+}
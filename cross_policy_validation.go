@@ -0,0 +1,104 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// authIdentitySelector prefixes the well known selectors resolved out of the identity an AuthPolicy authenticates.
+const authIdentitySelector = "auth.identity"
+
+// RoutePolicies are the effective policies of all kinds on a same route.
+type RoutePolicies struct {
+	Auth      AuthPolicy
+	RateLimit RateLimitPolicy
+}
+
+// CrossPolicyViolation is a policy relying on something another kind of policy of the route doesn't provide.
+type CrossPolicyViolation struct {
+	// Limit is the rate limit whose condition or counter, as Field tells, refers to Selector.
+	Limit    string
+	Field    string
+	Selector ContextSelector
+
+	Message string
+}
+
+func (v CrossPolicyViolation) Error() string {
+	return fmt.Sprintf("limit %s: %s %s: %s", v.Limit, v.Field, v.Selector, v.Message)
+}
+
+type CrossPolicyViolations []CrossPolicyViolation
+
+func (v CrossPolicyViolations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, violation := range v {
+		messages = append(messages, violation.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// RoutePoliciesOf merges the policies of all kinds of the route, of any kind, given as it is in the hierarchy of each
+// kind. It fails if they aren't the same route, i.e. of the same kind and name on the same gateway. On a route of a
+// kind AuthPolicy doesn't support, such as a TcpRoute, nobody is ever authenticated.
+func RoutePoliciesOf(auth routeNode[AuthPolicy], rateLimit routeNode[RateLimitPolicy]) (RoutePolicies, error) {
+	authRef, rateLimitRef := routeRefOf(auth), routeRefOf(rateLimit)
+	if authRef != rateLimitRef {
+		return RoutePolicies{}, fmt.Errorf("different routes: %s for auth, %s for rate limiting", authRef, rateLimitRef)
+	}
+	return RoutePolicies{
+		Auth:      auth.MergedPolicies(AuthPolicyMerger),
+		RateLimit: rateLimit.MergedPolicies(RateLimitPolicyMerger),
+	}, nil
+}
+
+// Validate lists the conditions and counters of the rate limits referring to `auth.identity`, while the AuthPolicy
+// authenticates no one, as it has no identity or only anonymous ones: the selectors would never resolve. Violations
+// are sorted by limit name.
+func (p RoutePolicies) Validate() CrossPolicyViolations {
+	var message string
+	switch {
+	case len(p.Auth.Identity) == 0:
+		message = "the effective AuthPolicy has no identity"
+	case onlyAnonymousIdentities(p.Auth):
+		message = "the effective AuthPolicy only has anonymous identities"
+	default:
+		return nil
+	}
+
+	names := make([]string, 0, len(p.RateLimit.Limits))
+	for name := range p.RateLimit.Limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations CrossPolicyViolations
+	for _, name := range names {
+		limit := p.RateLimit.Limits[name]
+		for _, condition := range limit.When {
+			if refersToAuthIdentity(condition.Selector) {
+				violations = append(violations, CrossPolicyViolation{Limit: name, Field: "when", Selector: condition.Selector, Message: message})
+			}
+		}
+		for _, counter := range limit.Counters {
+			if refersToAuthIdentity(counter) {
+				violations = append(violations, CrossPolicyViolation{Limit: name, Field: "counters", Selector: counter, Message: message})
+			}
+		}
+	}
+	return violations
+}
+
+func onlyAnonymousIdentities(policy AuthPolicy) bool {
+	for _, identity := range policy.Identity {
+		if identity.Anonymous == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func refersToAuthIdentity(selector ContextSelector) bool {
+	return string(selector) == authIdentitySelector || strings.HasPrefix(string(selector), authIdentitySelector+".")
+}
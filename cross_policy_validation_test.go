@@ -0,0 +1,64 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+func TestRoutePolicies_Validate(t *testing.T) {
+	authClass := NewGatewayClass[AuthPolicy]("gwc")
+	authRoute := authClass.CreateGateway("gw").CreateRoute("route")
+	rateLimitClass := NewGatewayClass[RateLimitPolicy]("gwc")
+	rateLimitGateway := rateLimitClass.CreateGateway("gw")
+	rateLimitRoute := rateLimitGateway.CreateRoute("route")
+	rateLimitGateway.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw", defaults: testRateLimitPolicySpec2})
+
+	policies, err := RoutePoliciesOf(authRoute, rateLimitRoute)
+	assert.NilError(t, err)
+	violations := policies.Validate()
+	assert.Equal(t, violations.Error(), "limit per-user: counters auth.identity.username: the effective AuthPolicy has no identity; "+
+		"limit subnet: when auth.identity.group: the effective AuthPolicy has no identity")
+
+	authRoute.AddPolicy(PolicySpec[AuthPolicy]{name: "anonymous", defaults: testAuthPolicySpec1})
+	policies, err = RoutePoliciesOf(authRoute, rateLimitRoute)
+	assert.NilError(t, err)
+	violations = policies.Validate()
+	assert.Equal(t, len(violations), 2)
+	assert.Equal(t, violations[0], CrossPolicyViolation{
+		Limit:    "per-user",
+		Field:    "counters",
+		Selector: "auth.identity.username",
+		Message:  "the effective AuthPolicy only has anonymous identities",
+	})
+
+	authRoute.AddPolicy(PolicySpec[AuthPolicy]{name: "api-key", overrides: AuthPolicy{Identity: []*authorino.Identity{
+		{Name: "api-key", APIKey: &authorino.Identity_APIKey{}},
+	}}})
+	policies, err = RoutePoliciesOf(authRoute, rateLimitRoute)
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies.Validate()), 0)
+
+	assert.Equal(t, len(RoutePolicies{RateLimit: testRateLimitPolicySpec1}.Validate()), 0)
+}
+
+func TestRoutePoliciesOf_Routes(t *testing.T) {
+	authClass := NewGatewayClass[AuthPolicy]("gwc")
+	authGateway := authClass.CreateGateway("gw")
+	rateLimitClass := NewGatewayClass[RateLimitPolicy]("gwc")
+	rateLimitGateway := rateLimitClass.CreateGateway("gw")
+	rateLimitGateway.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw", defaults: testRateLimitPolicySpec2})
+
+	authRoute := authGateway.CreateGrpcRoute("toys", GrpcMethodMatch{Service: "toystore.Toys"})
+	authRoute.AddPolicy(PolicySpec[AuthPolicy]{name: "anonymous", defaults: testAuthPolicySpec1})
+	policies, err := RoutePoliciesOf(authRoute, rateLimitGateway.CreateGrpcRoute("toys"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies.Validate()), 2)
+
+	_, err = RoutePoliciesOf(authRoute, rateLimitGateway.CreateRoute("toys"))
+	assert.Error(t, err, "different routes: GRPCRoute gw/toys for auth, gw/toys for rate limiting")
+	_, err = RoutePoliciesOf(authGateway.CreateRoute("toys"), rateLimitGateway.CreateRoute("other"))
+	assert.Error(t, err, "different routes: gw/toys for auth, gw/other for rate limiting")
+}
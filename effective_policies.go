@@ -0,0 +1,89 @@
+package gw_policies_playground
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+type RouteResult[T Policy] struct {
+	Policy T
+	Err    error
+}
+
+// EffectivePolicies holds the result of merging the policies of every route, of all kinds, keyed by route.
+type EffectivePolicies[T Policy] map[RouteRef]RouteResult[T]
+
+// Get looks up a route, an HTTPRoute being referred to with or without its kind.
+func (e EffectivePolicies[T]) Get(ref RouteRef) (RouteResult[T], bool) {
+	result, exists := e[ref.normalized()]
+	return result, exists
+}
+
+// AllMergedPolicies computes the MergedPolicies of every route of every gateway of the class, of all kinds, using up
+// to workers goroutines (GOMAXPROCS if workers isn't positive). All the routes are merged off the same snapshot of the
+// tree, which can't be mutated until the call returns.
+//
+// A merger that panics only fails the route being merged. If ctx is done before all the routes are merged, the
+// remaining ones carry ctx's error, which is returned as well. Once all of them are merged, ctx being done is no error.
+func (gwc *GatewayClass[T]) AllMergedPolicies(ctx context.Context, merger func(T, T) T, workers int) (EffectivePolicies[T], error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	gwc.lock.RLock()
+	defer gwc.lock.RUnlock()
+
+	routes := routesBeneath[T](gwc)
+	results := make([]RouteResult[T], len(routes))
+	merged := make([]bool, len(routes))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results[job] = mergeRoute(routes[job], merger)
+				merged[job] = true
+			}
+		}()
+	}
+
+dispatch:
+	for job := range routes {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- job:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var err error
+	effective := make(EffectivePolicies[T], len(routes))
+	for i, route := range routes {
+		if !merged[i] {
+			err = ctx.Err()
+			results[i].Err = err
+		}
+		effective[routeRefOf(route)] = results[i]
+	}
+	return effective, err
+}
+
+func mergeRoute[T Policy](route routeNode[T], merger func(T, T) T) (result RouteResult[T]) {
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("merging %s: %v", routeRefOf(route), r)
+		}
+	}()
+	result.Policy = foldContributions(applicableContributions(route), merger)
+	return result
+}
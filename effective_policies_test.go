@@ -0,0 +1,121 @@
+package gw_policies_playground
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func testFleet(gateways, routes int) *GatewayClass[FakePolicy] {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	enabled := true
+	gwc.AddPolicy(PolicySpec[FakePolicy]{name: "class", overrides: FakePolicy{enabled: &enabled}})
+	for i := 0; i < gateways; i++ {
+		gw := gwc.CreateGateway(fmt.Sprintf("gw-%d", i))
+		for j := 0; j < routes; j++ {
+			value := i*routes + j
+			gw.CreateRoute(fmt.Sprintf("route-%d", j)).AddPolicy(PolicySpec[FakePolicy]{
+				name:     "route",
+				defaults: FakePolicy{value: &value},
+			})
+		}
+	}
+	return &gwc
+}
+
+func TestAllMergedPolicies(t *testing.T) {
+	gwc := testFleet(20, 500)
+
+	effective, err := gwc.AllMergedPolicies(context.Background(), FakePolicyMerger, 8)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(effective), 20*500)
+	result, exists := effective.Get(RouteRef{Gateway: "gw-3", Route: "route-42"})
+	assert.Check(t, exists)
+	assert.NilError(t, result.Err)
+	assert.Equal(t, *result.Policy.value, 3*500+42)
+	assert.Check(t, *result.Policy.enabled)
+
+	_, exists = effective.Get(RouteRef{Gateway: "gw-3", Route: "route-500"})
+	assert.Check(t, !exists)
+}
+
+func TestAllMergedPolicies_RouteKinds(t *testing.T) {
+	gwc := testFleet(1, 1)
+	gw, _ := gwc.Gateway("gw-0")
+	gw.CreateGrpcRoute("route-0")
+	gw.CreateTcpRoute("route-0")
+
+	effective, err := gwc.AllMergedPolicies(context.Background(), FakePolicyMerger, 0)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(effective), 3)
+	http, _ := effective.Get(RouteRef{Gateway: "gw-0", Route: "route-0", Kind: HTTPRouteKind})
+	assert.Equal(t, *http.Policy.value, 0)
+	grpc, exists := effective.Get(RouteRef{Gateway: "gw-0", Route: "route-0", Kind: GRPCRouteKind})
+	assert.Check(t, exists)
+	assert.Check(t, grpc.Policy.value == nil)
+	assert.Check(t, *grpc.Policy.enabled)
+}
+
+func TestAllMergedPolicies_PerRouteErrors(t *testing.T) {
+	gwc := testFleet(2, 10)
+
+	merger := func(p1 FakePolicy, p2 FakePolicy) FakePolicy {
+		if p2.value != nil && *p2.value == 13 {
+			panic("unlucky")
+		}
+		return FakePolicyMerger(p1, p2)
+	}
+	effective, err := gwc.AllMergedPolicies(context.Background(), merger, 0)
+
+	assert.NilError(t, err)
+	failed, _ := effective.Get(RouteRef{Gateway: "gw-1", Route: "route-3"})
+	assert.ErrorContains(t, failed.Err, "merging gw-1/route-3: unlucky")
+	ok, _ := effective.Get(RouteRef{Gateway: "gw-1", Route: "route-4"})
+	assert.NilError(t, ok.Err)
+	assert.Equal(t, *ok.Policy.value, 14)
+}
+
+func TestAllMergedPolicies_Cancelled(t *testing.T) {
+	gwc := testFleet(2, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	effective, err := gwc.AllMergedPolicies(ctx, FakePolicyMerger, 4)
+
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, len(effective), 2*10)
+	for _, result := range effective {
+		assert.Equal(t, result.Err, context.Canceled)
+	}
+}
+
+func TestAllMergedPolicies_CancelledOnceMerged(t *testing.T) {
+	gwc := testFleet(1, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	merger := func(p1 FakePolicy, p2 FakePolicy) FakePolicy {
+		cancel()
+		return FakePolicyMerger(p1, p2)
+	}
+	effective, err := gwc.AllMergedPolicies(ctx, merger, 1)
+
+	assert.NilError(t, err)
+	result, _ := effective.Get(RouteRef{Gateway: "gw-0", Route: "route-0"})
+	assert.NilError(t, result.Err)
+	assert.Equal(t, *result.Policy.value, 0)
+}
+
+func BenchmarkAllMergedPolicies(b *testing.B) {
+	gwc := testFleet(10, 5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := gwc.AllMergedPolicies(context.Background(), FakePolicyMerger, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package gw_policies_playground
 
+import "sync"
+
 type void struct{}
 
 var sentinel void
@@ -7,74 +9,262 @@ var sentinel void
 type GatewayClass[T Policy] struct {
 	name     string
 	gateways map[*Gateway[T]]void
+	services map[*Service[T]]void
 	policies []PolicySpec[T]
+
+	constraints []Constraint[T]
+
+	// generation changes whenever the policies attached at this level do.
+	generation uint64
+
+	// lock guards the whole tree: mutations at any level are exclusive, so that reads (e.g. a merge) see a consistent
+	// snapshot of all the levels involved.
+	lock *sync.RWMutex
+
+	// watchers are notified of every mutation, under the lock.
+	watchers map[*watcher[T]]void
 }
 
 func (gwc *GatewayClass[T]) CreateGateway(name string) *Gateway[T] {
+	gwc.lock.Lock()
+	defer gwc.lock.Unlock()
+
 	gw := &Gateway[T]{
-		parent: gwc,
-		name:   name,
-		routes: make(map[*HttpRoute[T]]void),
+		parent:     gwc,
+		name:       name,
+		routes:     make(map[*HttpRoute[T]]void),
+		grpcRoutes: make(map[*GrpcRoute[T]]void),
+		tcpRoutes:  make(map[*TcpRoute[T]]void),
+		tlsRoutes:  make(map[*TlsRoute[T]]void),
 	}
 	gwc.gateways[gw] = sentinel
 	return gw
 }
 
+func (gwc *GatewayClass[T]) AddPolicy(policy PolicySpec[T]) {
+	gwc.lock.Lock()
+	defer gwc.lock.Unlock()
+
+	gwc.policies = append(gwc.policies, policy)
+	gwc.generation++
+	gwc.notify(gwc, Mutation{Kind: PolicyAttached, TargetKind: "GatewayClass", TargetName: gwc.name, Policy: policy.name})
+}
+
+// RemovePolicy detaches the policies of that name from the class, telling whether there was any.
+func (gwc *GatewayClass[T]) RemovePolicy(name string) bool {
+	gwc.lock.Lock()
+	defer gwc.lock.Unlock()
+
+	var removed bool
+	if gwc.policies, removed = removePolicy(gwc.policies, name); removed {
+		gwc.generation++
+		gwc.notify(gwc, Mutation{Kind: PolicyDetached, TargetKind: "GatewayClass", TargetName: gwc.name, Policy: name})
+	}
+	return removed
+}
+
+// Gateway looks up a gateway of the class by name.
+func (gwc *GatewayClass[T]) Gateway(name string) (*Gateway[T], bool) {
+	gwc.lock.RLock()
+	defer gwc.lock.RUnlock()
+
+	for gw := range gwc.gateways {
+		if gw.name == name {
+			return gw, true
+		}
+	}
+	return nil, false
+}
+
 type Gateway[T Policy] struct {
-	parent   *GatewayClass[T]
-	name     string
-	routes   map[*HttpRoute[T]]void
-	policies []PolicySpec[T]
+	parent     *GatewayClass[T]
+	name       string
+	routes     map[*HttpRoute[T]]void
+	grpcRoutes map[*GrpcRoute[T]]void
+	tcpRoutes  map[*TcpRoute[T]]void
+	tlsRoutes  map[*TlsRoute[T]]void
+	listeners  []Listener
+	policies   []PolicySpec[T]
+
+	constraints []Constraint[T]
+
+	generation uint64
 }
 
 func (gw *Gateway[T]) CreateRoute(name string) *HttpRoute[T] {
-	r := &HttpRoute[T]{
-		parent: gw,
-		name:   name,
-	}
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	r := &HttpRoute[T]{routeBase: routeBase[T]{parent: gw, name: name}}
+	r.node = r
 	gw.routes[r] = sentinel
+	gw.parent.notify(r, Mutation{Kind: RouteCreated, TargetKind: string(HTTPRouteKind), TargetName: name, Gateway: gw.name})
 	return r
 }
 
 func (gw *Gateway[T]) AddPolicy(policy PolicySpec[T]) {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
 	gw.policies = append(gw.policies, policy)
+	gw.generation++
+	gw.parent.notify(gw, Mutation{Kind: PolicyAttached, TargetKind: "Gateway", TargetName: gw.name, Policy: policy.name})
+}
+
+func (gw *Gateway[T]) RemovePolicy(name string) bool {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	var removed bool
+	if gw.policies, removed = removePolicy(gw.policies, name); removed {
+		gw.generation++
+		gw.parent.notify(gw, Mutation{Kind: PolicyDetached, TargetKind: "Gateway", TargetName: gw.name, Policy: name})
+	}
+	return removed
+}
+
+// Route looks up a route of the gateway by name.
+func (gw *Gateway[T]) Route(name string) (*HttpRoute[T], bool) {
+	gw.parent.lock.RLock()
+	defer gw.parent.lock.RUnlock()
+
+	for r := range gw.routes {
+		if r.name == name {
+			return r, true
+		}
+	}
+	return nil, false
 }
 
 type HttpRoute[T Policy] struct {
-	parent   *Gateway[T]
+	routeBase[T]
+	hostnames   []string
+	sectionName string
+	rules       []RouteRule[T]
+}
+
+// policyContribution is either the defaults or the overrides of an attached PolicySpec, as folded by MergedPolicies.
+type policyContribution[T Policy] struct {
+	// target is the *GatewayClass[T], *Gateway[T] or the route of any kind the policy is attached to.
+	target   interface{}
+	index    int
 	name     string
-	policies []PolicySpec[T]
+	override bool
+	policy   T
+
+	// direct is set for Direct policies, which only apply to the object they're attached to.
+	direct bool
 }
 
-func (r *HttpRoute[T]) AddPolicy(policy PolicySpec[T]) {
-	r.policies = append(r.policies, policy)
+// contributions returns the policies of the gateway and its class, from the highest precedence to the lowest.
+func (gw *Gateway[T]) contributions() []policyContribution[T] {
+	contributions := addContributions(nil, gw, gw.policies)
+	return addContributions(contributions, gw.parent, gw.parent.policies)
 }
 
-func (r *HttpRoute[T]) MergedPolicies(merger func(T, T) T) T {
-	var policies []T
-	for _, policy := range r.policies {
-		policies = append(policies, policy.defaults)
-		policies = append([]T{policy.overrides}, policies...)
-	}
-	for _, policy := range r.parent.policies {
-		policies = append(policies, policy.defaults)
-		policies = append([]T{policy.overrides}, policies...)
+// addContributions adds the policies of target, which must be an ancestor of the targets already added: its
+// overrides take precedence over theirs, while its defaults come last.
+func addContributions[T Policy](contributions []policyContribution[T], target interface{}, policies []PolicySpec[T]) []policyContribution[T] {
+	for i, policy := range policies {
+		direct := policy.Class() == DirectPolicyClass
+		contributions = append(contributions, policyContribution[T]{
+			target: target,
+			index:  i,
+			name:   policy.name,
+			policy: policy.defaults,
+			direct: direct,
+		})
+		contributions = append([]policyContribution[T]{{
+			target:   target,
+			index:    i,
+			name:     policy.name,
+			override: true,
+			policy:   policy.overrides,
+			direct:   direct,
+		}}, contributions...)
 	}
-	for _, policy := range r.parent.parent.policies {
-		policies = append(policies, policy.defaults)
-		policies = append([]T{policy.overrides}, policies...)
+	return contributions
+}
+
+func foldContributions[T Policy](contributions []policyContribution[T], merger func(T, T) T) T {
+	if len(contributions) == 0 {
+		var none T
+		return none
 	}
-	result := policies[0]
-	for _, policy := range policies[1:] {
-		result = merger(result, policy)
+	result := contributions[0].policy
+	for _, contribution := range contributions[1:] {
+		result = merger(result, contribution.policy)
 	}
 	return result
 }
 
+// MergeContext is what a contextual merger knows of the policy it merges in.
+type MergeContext struct {
+	// Level is the kind of object the policy is attached to, i.e. GatewayClass, Gateway or the RouteKind of a route.
+	Level string
+
+	// Override is set when merging in overrides, defaults otherwise.
+	Override bool
+}
+
+// MergedPoliciesInContext is MergedPolicies for mergers that tell a parent from a child, rather than a higher from a
+// lower precedence policy, and may depend on where the policy being merged in is attached. Policies are merged in from
+// the lowest precedence to the highest, starting from the zero T: a default refines what it inherits, so it's merged
+// in as its child, while an override is imposed on what it inherits, so it's merged in as its parent. Empty policies
+// are left out. The merge stops at the first error of merger, which is returned as is.
+func (r *HttpRoute[T]) MergedPoliciesInContext(merger func(parent, child T, context MergeContext) (T, error)) (T, error) {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	var result, empty T
+	contributions := applicableContributions[T](r)
+	for i := len(contributions) - 1; i >= 0; i-- {
+		contribution := contributions[i]
+		if len(DiffPolicies(empty, contribution.policy)) == 0 {
+			continue
+		}
+		level, _ := targetKindAndName[T](contribution.target)
+		context := MergeContext{Level: level, Override: contribution.override}
+		var err error
+		if contribution.override {
+			result, err = merger(contribution.policy, result, context)
+		} else {
+			result, err = merger(result, contribution.policy, context)
+		}
+		if err != nil {
+			var none T
+			return none, err
+		}
+	}
+	return result, nil
+}
+
 type PolicySpec[T Policy] struct {
 	name      string
 	defaults  T
 	overrides T
+
+	// class is empty when the policy is classified as its type is.
+	class PolicyClass
+}
+
+func NewPolicySpec[T Policy](name string, defaults, overrides T) PolicySpec[T] {
+	return PolicySpec[T]{
+		name:      name,
+		defaults:  defaults,
+		overrides: overrides,
+	}
+}
+
+// removePolicy keeps the order of the remaining policies, i.e. their precedence.
+func removePolicy[T Policy](policies []PolicySpec[T], name string) ([]PolicySpec[T], bool) {
+	kept := make([]PolicySpec[T], 0, len(policies))
+	for _, policy := range policies {
+		if policy.name != name {
+			kept = append(kept, policy)
+		}
+	}
+	return kept, len(kept) < len(policies)
 }
 
 type Policy interface {
@@ -84,5 +274,8 @@ func NewGatewayClass[T Policy](name string) GatewayClass[T] {
 	return GatewayClass[T]{
 		name:     name,
 		gateways: make(map[*Gateway[T]]void),
+		services: make(map[*Service[T]]void),
+		lock:     &sync.RWMutex{},
+		watchers: make(map[*watcher[T]]void),
 	}
 }
@@ -0,0 +1,119 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestConcurrentMutationsAndMerges(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	const writers = 4
+	const mutations = 30
+
+	enabled := true
+	cache := NewMergeCache(FakePolicyMerger)
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				result := route.MergedPolicies(FakePolicyMerger)
+				if result.value != nil {
+					assert.Check(t, *result.value >= 0)
+				}
+				cache.MergedPolicies(route)
+				DiffEffectivePolicies(&gwc, &gwc, FakePolicyMerger)
+				runtime.Gosched()
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for j := 0; j < mutations; j++ {
+				value := writer*mutations + j
+				spec := PolicySpec[FakePolicy]{
+					name:      fmt.Sprintf("policy-%d", value),
+					defaults:  FakePolicy{value: &value},
+					overrides: FakePolicy{enabled: &enabled},
+				}
+				switch j % 3 {
+				case 0:
+					gwc.AddPolicy(spec)
+				case 1:
+					gw.AddPolicy(spec)
+				case 2:
+					route.AddPolicy(spec)
+				}
+				gwc.CreateGateway(fmt.Sprintf("gw-%d", value)).CreateRoute("route")
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	assert.Equal(t, len(gwc.policies)+len(gw.policies)+len(route.policies), writers*mutations)
+	assert.Equal(t, len(gwc.gateways), writers*mutations+1)
+
+	result := route.MergedPolicies(FakePolicyMerger)
+	assert.Check(t, *result.enabled)
+	assert.Equal(t, result.value, route.policies[0].defaults.value)
+}
+
+// A merge must never observe a tree in which only some of the levels reflect a concurrent change: a route's result
+// always matches one of the states a sequential history of mutations goes through.
+func TestConcurrentMergeIsSnapshotConsistent(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	const mutations = 200
+
+	values := make([]int, mutations)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range values {
+			values[i] = i
+			gw.AddPolicy(PolicySpec[FakePolicy]{
+				name:      fmt.Sprintf("policy-%d", i),
+				overrides: FakePolicy{value: &values[i]},
+			})
+		}
+	}()
+
+	last := -1
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		result := route.MergedPolicies(FakePolicyMerger)
+		if result.value == nil {
+			assert.Equal(t, last, -1)
+			continue
+		}
+		assert.Check(t, *result.value >= last, "merge went back in time: %d after %d", *result.value, last)
+		last = *result.value
+	}
+	assert.Equal(t, *route.MergedPolicies(FakePolicyMerger).value, mutations-1)
+}
@@ -0,0 +1,75 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GrpcMethodMatch selects the gRPC calls of a GrpcRoute rule. An empty Service or Method matches any.
+type GrpcMethodMatch struct {
+	// Service is the fully qualified service name, i.e. `package.Service`.
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+// Path is the HTTP/2 path of the calls matched, i.e. `/package.Service/Method`, if both Service and Method are set.
+func (m GrpcMethodMatch) Path() (string, bool) {
+	if m.Service == "" || m.Method == "" {
+		return "", false
+	}
+	return fmt.Sprintf("/%s/%s", m.Service, m.Method), true
+}
+
+func (m GrpcMethodMatch) pathRegexp() string {
+	service, method := "[^/]+", "[^/]+"
+	if m.Service != "" {
+		service = regexp.QuoteMeta(m.Service)
+	}
+	if m.Method != "" {
+		method = regexp.QuoteMeta(m.Method)
+	}
+	return fmt.Sprintf("/%s/%s", service, method)
+}
+
+// GrpcRoute sits next to the HttpRoutes of a Gateway, and merges the policies of the hierarchy the same way. Its
+// effective policy only applies to the calls its matches select, no match selecting them all.
+type GrpcRoute[T Policy] struct {
+	routeBase[T]
+	hostnames   []string
+	sectionName string
+	matches     []GrpcMethodMatch
+}
+
+func (gw *Gateway[T]) CreateGrpcRoute(name string, matches ...GrpcMethodMatch) *GrpcRoute[T] {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	r := &GrpcRoute[T]{
+		routeBase: routeBase[T]{parent: gw, name: name},
+		matches:   append([]GrpcMethodMatch{}, matches...),
+	}
+	r.node = r
+	gw.grpcRoutes[r] = sentinel
+	gw.parent.notify(r, Mutation{Kind: RouteCreated, TargetKind: string(GRPCRouteKind), TargetName: name, Gateway: gw.name})
+	return r
+}
+
+func (r *GrpcRoute[T]) Matches() []GrpcMethodMatch {
+	return append([]GrpcMethodMatch{}, r.matches...)
+}
+
+// grpcPathPattern matches the `context.request.http.path` of the calls selected by matches: a single path is compared
+// for equality, while anything else is turned into a regular expression.
+func grpcPathPattern(matches []GrpcMethodMatch) (operator string, value string) {
+	if len(matches) == 1 {
+		if path, exact := matches[0].Path(); exact {
+			return "eq", path
+		}
+	}
+	alternatives := make([]string, 0, len(matches))
+	for _, match := range matches {
+		alternatives = append(alternatives, match.pathRegexp())
+	}
+	return "matches", fmt.Sprintf("^(%s)$", strings.Join(alternatives, "|"))
+}
@@ -0,0 +1,61 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+func TestGrpcRouteMerge(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateGrpcRoute("grpc", GrpcMethodMatch{Service: "toystore.v1.Toys", Method: "List"})
+
+	enabled, disabled := true, false
+	value := 42
+
+	gw.AddPolicy(NewPolicySpec("gw", FakePolicy{value: &value}, FakePolicy{enabled: &disabled}))
+	route.AddPolicy(NewPolicySpec("route", FakePolicy{enabled: &enabled}, FakePolicy{}))
+
+	result := route.MergedPolicies(FakePolicyMerger)
+	assert.Equal(t, *result.value, 42)
+	assert.Check(t, !*result.enabled)
+
+	assert.DeepEqual(t, gw.AffectingPolicies(FakePolicyMerger), []AffectingPolicy{
+		{Name: "gw", TargetKind: "Gateway", TargetName: "gw", Class: InheritedPolicyClass, Participation: ParticipationOverride},
+		{Name: "gw", TargetKind: "Gateway", TargetName: "gw", Class: InheritedPolicyClass, Participation: ParticipationDefault},
+	})
+	assert.Equal(t, route.AffectingPolicies(FakePolicyMerger)[1].Participation, ParticipationShadowed)
+}
+
+func TestGrpcRouteAuthPolicy(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	gw.AddPolicy(NewPolicySpec("gw", testAuthPolicySpec1, AuthPolicy{}))
+
+	exact := gw.CreateGrpcRoute("exact", GrpcMethodMatch{Service: "toystore.v1.Toys", Method: "List"})
+	policy := GrpcRouteAuthPolicy(exact)
+	assert.Equal(t, len(policy.Conditions), len(testAuthPolicySpec1.Conditions)+1)
+	assert.DeepEqual(t, policy.Conditions[len(policy.Conditions)-1].JSONPatternExpression, authorino.JSONPatternExpression{
+		Selector: "context.request.http.path",
+		Operator: "eq",
+		Value:    "/toystore.v1.Toys/List",
+	})
+	assert.Equal(t, len(testAuthPolicySpec1.Conditions), len(exact.MergedPolicies(AuthPolicyMerger).Conditions))
+
+	many := gw.CreateGrpcRoute("many",
+		GrpcMethodMatch{Service: "toystore.v1.Toys", Method: "List"},
+		GrpcMethodMatch{Service: "toystore.v1.Orders"},
+	)
+	policy = GrpcRouteAuthPolicy(many)
+	assert.DeepEqual(t, policy.Conditions[len(policy.Conditions)-1].JSONPatternExpression, authorino.JSONPatternExpression{
+		Selector: "context.request.http.path",
+		Operator: "matches",
+		Value:    `^(/toystore\.v1\.Toys/List|/toystore\.v1\.Orders/[^/]+)$`,
+	})
+
+	all := gw.CreateGrpcRoute("all")
+	assert.DeepEqual(t, GrpcRouteAuthPolicy(all).Conditions, testAuthPolicySpec1.Conditions)
+}
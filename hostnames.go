@@ -0,0 +1,121 @@
+package gw_policies_playground
+
+import "strings"
+
+// Listener is where a Gateway accepts traffic for. An empty Hostname matches any host.
+type Listener struct {
+	Name     string `json:"name"`
+	Hostname string `json:"hostname,omitempty"`
+}
+
+func (gw *Gateway[T]) AddListener(listener Listener) {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	gw.listeners = append(gw.listeners, listener)
+}
+
+// SetHostnames restricts the route to the hosts matching any of hostnames, all of the hosts of the listeners if none.
+func (r *HttpRoute[T]) SetHostnames(hostnames ...string) {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	r.hostnames = append([]string{}, hostnames...)
+}
+
+// SetSectionName attaches the route to the listener of the gateway by that name only, as a parentRef's sectionName
+// does. All the listeners apply if empty.
+func (r *HttpRoute[T]) SetSectionName(listener string) {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	r.sectionName = listener
+}
+
+// Hosts are the hosts the route serves on its gateway: the intersection of its hostnames with the ones of the
+// listeners it's attached to, `*` standing for any host. A gateway without listeners accepts any host, while a route
+// whose hostnames intersect with none of the listeners has no hosts at all.
+func (r *HttpRoute[T]) Hosts() []string {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	return listenersHosts(r.parent.listeners, r.sectionName, r.hostnames)
+}
+
+// SetHostnames restricts the route to the hosts matching any of hostnames, as for HttpRoute.
+func (r *GrpcRoute[T]) SetHostnames(hostnames ...string) {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	r.hostnames = append([]string{}, hostnames...)
+}
+
+// SetSectionName attaches the route to the listener of the gateway by that name only, as for HttpRoute.
+func (r *GrpcRoute[T]) SetSectionName(listener string) {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	r.sectionName = listener
+}
+
+// Hosts are the hosts the route serves on its gateway, following the same rules as for HttpRoute.
+func (r *GrpcRoute[T]) Hosts() []string {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	return listenersHosts(r.parent.listeners, r.sectionName, r.hostnames)
+}
+
+// Hosts are the SNI names the route serves on its gateway, following the same rules as for HttpRoute.
+func (r *TlsRoute[T]) Hosts() []string {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	return listenersHosts(r.parent.listeners, "", r.hostnames)
+}
+
+func listenersHosts(listeners []Listener, sectionName string, hostnames []string) []string {
+	if len(listeners) == 0 {
+		listeners = []Listener{{}}
+	}
+	var hosts []string
+	for _, listener := range listeners {
+		if sectionName != "" && listener.Name != sectionName {
+			continue
+		}
+		for _, host := range IntersectHostnames(listener.Hostname, hostnames) {
+			hosts = appendUnique(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// IntersectHostnames follows the Gateway API rules to match the hostname of a listener with the ones of a route. A
+// wildcard hostname, e.g. `*.example.com`, matches any host with that suffix and at least one more label, but not
+// `example.com` itself. The most specific of two matching hostnames is the one retained.
+func IntersectHostnames(listener string, route []string) []string {
+	if len(route) == 0 {
+		if listener == "" {
+			return []string{"*"}
+		}
+		return []string{listener}
+	}
+	var hosts []string
+	for _, hostname := range route {
+		switch {
+		case listener == "" || listener == hostname || wildcardMatches(listener, hostname):
+			hosts = appendUnique(hosts, hostname)
+		case wildcardMatches(hostname, listener):
+			hosts = appendUnique(hosts, listener)
+		}
+	}
+	return hosts
+}
+
+func wildcardMatches(wildcard, hostname string) bool {
+	if !strings.HasPrefix(wildcard, "*.") {
+		return false
+	}
+	suffix := wildcard[1:]
+	return len(hostname) > len(suffix) && strings.HasSuffix(hostname, suffix)
+}
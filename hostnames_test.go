@@ -0,0 +1,52 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestIntersectHostnames(t *testing.T) {
+	cases := []struct {
+		listener string
+		route    []string
+		expected []string
+	}{
+		{"", nil, []string{"*"}},
+		{"", []string{"api.example.com"}, []string{"api.example.com"}},
+		{"*.example.com", nil, []string{"*.example.com"}},
+		{"api.example.com", []string{"api.example.com", "www.example.com"}, []string{"api.example.com"}},
+		{"*.example.com", []string{"api.example.com", "v1.api.example.com", "example.com"}, []string{"api.example.com", "v1.api.example.com"}},
+		{"api.example.com", []string{"*.example.com"}, []string{"api.example.com"}},
+		{"*.example.com", []string{"*.api.example.com", "*.example.com"}, []string{"*.api.example.com", "*.example.com"}},
+		{"*.api.example.com", []string{"*.example.com"}, []string{"*.api.example.com"}},
+		{"api.example.com", []string{"*.other.com", "example.com"}, nil},
+	}
+	for _, c := range cases {
+		assert.DeepEqual(t, IntersectHostnames(c.listener, c.route), c.expected)
+	}
+}
+
+func TestRouteHosts(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	assert.DeepEqual(t, route.Hosts(), []string{"*"})
+
+	gw.AddListener(Listener{Name: "api", Hostname: "*.api.example.com"})
+	gw.AddListener(Listener{Name: "www", Hostname: "www.example.com"})
+	assert.DeepEqual(t, route.Hosts(), []string{"*.api.example.com", "www.example.com"})
+
+	route.SetHostnames("*.example.com")
+	assert.DeepEqual(t, route.Hosts(), []string{"*.api.example.com", "www.example.com"})
+
+	route.SetSectionName("www")
+	assert.DeepEqual(t, route.Hosts(), []string{"www.example.com"})
+
+	route.SetHostnames("v1.api.example.com")
+	assert.Equal(t, len(route.Hosts()), 0)
+
+	tls := gw.CreateTlsRoute("tls", "db.api.example.com", "db.example.com")
+	assert.DeepEqual(t, tls.Hosts(), []string{"db.api.example.com"})
+}
@@ -0,0 +1,42 @@
+package gw_policies_playground
+
+// TcpRoute sits next to the HttpRoutes of a Gateway. Only the policy types supporting TCPRouteKind apply to it.
+type TcpRoute[T Policy] struct {
+	routeBase[T]
+}
+
+func (gw *Gateway[T]) CreateTcpRoute(name string) *TcpRoute[T] {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	r := &TcpRoute[T]{routeBase: routeBase[T]{parent: gw, name: name}}
+	r.node = r
+	gw.tcpRoutes[r] = sentinel
+	gw.parent.notify(r, Mutation{Kind: RouteCreated, TargetKind: string(TCPRouteKind), TargetName: name, Gateway: gw.name})
+	return r
+}
+
+// TlsRoute sits next to the HttpRoutes of a Gateway, routing TLS connections by SNI. Only the policy types supporting
+// TLSRouteKind apply to it.
+type TlsRoute[T Policy] struct {
+	routeBase[T]
+	hostnames []string
+}
+
+func (gw *Gateway[T]) CreateTlsRoute(name string, hostnames ...string) *TlsRoute[T] {
+	gw.parent.lock.Lock()
+	defer gw.parent.lock.Unlock()
+
+	r := &TlsRoute[T]{
+		routeBase: routeBase[T]{parent: gw, name: name},
+		hostnames: append([]string{}, hostnames...),
+	}
+	r.node = r
+	gw.tlsRoutes[r] = sentinel
+	gw.parent.notify(r, Mutation{Kind: RouteCreated, TargetKind: string(TLSRouteKind), TargetName: name, Gateway: gw.name})
+	return r
+}
+
+func (r *TlsRoute[T]) Hostnames() []string {
+	return append([]string{}, r.hostnames...)
+}
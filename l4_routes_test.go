@@ -0,0 +1,40 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestL4Routes_Unsupported(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	http := gw.CreateRoute("http")
+	tcp := gw.CreateTcpRoute("tcp")
+	tls := gw.CreateTlsRoute("tls", "db.toystore.io")
+
+	gw.AddPolicy(NewPolicySpec("gw", testAuthPolicySpec1, AuthPolicy{}))
+	tcp.AddPolicy(NewPolicySpec("tcp", testAuthPolicySpec2, AuthPolicy{}))
+
+	assert.Equal(t, len(http.MergedPolicies(AuthPolicyMerger).Identity), 1)
+	assert.DeepEqual(t, tcp.MergedPolicies(AuthPolicyMerger), AuthPolicy{})
+	assert.DeepEqual(t, tls.MergedPolicies(AuthPolicyMerger), AuthPolicy{})
+	assert.DeepEqual(t, tls.Hostnames(), []string{"db.toystore.io"})
+
+	assert.DeepEqual(t, tcp.AffectingPolicies(AuthPolicyMerger), []AffectingPolicy{
+		{Name: "tcp", TargetKind: "TCPRoute", TargetName: "tcp", Class: InheritedPolicyClass, Participation: ParticipationSkipped},
+		{Name: "gw", TargetKind: "Gateway", TargetName: "gw", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationSkipped},
+	})
+	assert.Equal(t, gw.AffectingPolicies(AuthPolicyMerger)[0].Participation, ParticipationDefault)
+}
+
+func TestL4Routes_Supported(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	tcp := gw.CreateTcpRoute("tcp")
+
+	gw.AddPolicy(NewPolicySpec("gw", testRateLimitPolicySpec1, RateLimitPolicy{}))
+
+	assert.DeepEqual(t, tcp.MergedPolicies(RateLimitPolicyMerger), testRateLimitPolicySpec1)
+	assert.Equal(t, tcp.AffectingPolicies(RateLimitPolicyMerger)[0].Participation, ParticipationDefault)
+}
@@ -0,0 +1,128 @@
+package gw_policies_playground
+
+import "sync"
+
+// MergeCache memoizes, per Gateway and GatewayClass, the partially merged override and default stacks that every route
+// beneath them shares, so that only a route's own policies are folded on each call. Direct policies aren't part of the
+// stacks, as routes don't inherit them. A stack is recomputed when the policies attached at its level, or at a level
+// above it, change.
+//
+// The override stack is a prefix of the fold MergedPolicies performs, but the default stack is merged ahead of time:
+// the merger needs to be associative, i.e. merger(merger(a, b), c) == merger(a, merger(b, c)), for both to agree.
+// Precedence based mergers, like AuthPolicyMerger, are.
+type MergeCache[T Policy] struct {
+	merger func(T, T) T
+
+	lock     sync.Mutex
+	classes  map[*GatewayClass[T]]*policyStack[T]
+	gateways map[*Gateway[T]]*policyStack[T]
+}
+
+// policyStack is the merged overrides and defaults of a level, and of all the levels above it.
+type policyStack[T Policy] struct {
+	generation       uint64
+	parentGeneration uint64
+
+	overrides    T
+	hasOverrides bool
+	defaults     T
+	hasDefaults  bool
+}
+
+func NewMergeCache[T Policy](merger func(T, T) T) *MergeCache[T] {
+	return &MergeCache[T]{
+		merger:   merger,
+		classes:  make(map[*GatewayClass[T]]*policyStack[T]),
+		gateways: make(map[*Gateway[T]]*policyStack[T]),
+	}
+}
+
+// MergedPolicies is equivalent to route.MergedPolicies(merger), for an associative merger.
+func (c *MergeCache[T]) MergedPolicies(r *HttpRoute[T]) T {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	if !supportsRouteKind[T](HTTPRouteKind) {
+		var none T
+		return none
+	}
+	stack := c.gatewayStack(r.parent)
+
+	var own []T
+	for _, policy := range r.policies {
+		own = append(own, policy.defaults)
+		own = append([]T{policy.overrides}, own...)
+	}
+
+	result, merged := stack.overrides, stack.hasOverrides
+	result, merged = c.fold(result, merged, own...)
+	if stack.hasDefaults {
+		result, _ = c.fold(result, merged, stack.defaults)
+	}
+	return result
+}
+
+func (c *MergeCache[T]) gatewayStack(gw *Gateway[T]) *policyStack[T] {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	class := c.classStack(gw.parent)
+	if stack, exists := c.gateways[gw]; exists && stack.generation == gw.generation && stack.parentGeneration == class.generation {
+		return stack
+	}
+
+	stack := &policyStack[T]{
+		generation:       gw.generation,
+		parentGeneration: class.generation,
+	}
+	var overrides, defaults []T
+	for _, policy := range gw.policies {
+		if policy.Class() == DirectPolicyClass {
+			continue
+		}
+		overrides = append([]T{policy.overrides}, overrides...)
+		defaults = append(defaults, policy.defaults)
+	}
+	stack.overrides, stack.hasOverrides = c.fold(class.overrides, class.hasOverrides, overrides...)
+	stack.defaults, stack.hasDefaults = c.fold(stack.defaults, false, defaults...)
+	if class.hasDefaults {
+		stack.defaults, stack.hasDefaults = c.fold(stack.defaults, stack.hasDefaults, class.defaults)
+	}
+	c.gateways[gw] = stack
+	return stack
+}
+
+// classStack expects the caller to hold the cache's lock.
+func (c *MergeCache[T]) classStack(gwc *GatewayClass[T]) *policyStack[T] {
+	if stack, exists := c.classes[gwc]; exists && stack.generation == gwc.generation {
+		return stack
+	}
+
+	stack := &policyStack[T]{
+		generation: gwc.generation,
+	}
+	var overrides, defaults []T
+	for _, policy := range gwc.policies {
+		if policy.Class() == DirectPolicyClass {
+			continue
+		}
+		overrides = append([]T{policy.overrides}, overrides...)
+		defaults = append(defaults, policy.defaults)
+	}
+	stack.overrides, stack.hasOverrides = c.fold(stack.overrides, false, overrides...)
+	stack.defaults, stack.hasDefaults = c.fold(stack.defaults, false, defaults...)
+	c.classes[gwc] = stack
+	return stack
+}
+
+// fold merges policies into result, in order, as MergedPolicies does. merged tells whether result holds anything yet.
+func (c *MergeCache[T]) fold(result T, merged bool, policies ...T) (T, bool) {
+	for _, policy := range policies {
+		if !merged {
+			result, merged = policy, true
+			continue
+		}
+		result = c.merger(result, policy)
+	}
+	return result, merged
+}
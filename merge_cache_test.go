@@ -0,0 +1,108 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+	"testing"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+func TestMergeCache_MatchesMergedPolicies(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gwc.AddPolicy(PolicySpec[AuthPolicy]{name: "class-defaults", defaults: testAuthPolicySpec2})
+	gw := gwc.CreateGateway("gw")
+	gw.AddPolicy(PolicySpec[AuthPolicy]{name: "gw-overrides", overrides: testAuthPolicySpec1})
+	gw.AddPolicy(PolicySpec[AuthPolicy]{name: "gw-defaults", defaults: testAuthPolicySpec2})
+
+	bare := gw.CreateRoute("bare")
+	route := gw.CreateRoute("route")
+	route.AddPolicy(PolicySpec[AuthPolicy]{
+		name:      "route-policy",
+		defaults:  testAuthPolicySpec1,
+		overrides: testAuthPolicySpec2,
+	})
+
+	cache := NewMergeCache(AuthPolicyMerger)
+	for _, r := range []*HttpRoute[AuthPolicy]{bare, route} {
+		assert.DeepEqual(t, DiffPolicies(cache.MergedPolicies(r), r.MergedPolicies(AuthPolicyMerger)), []FieldDiff(nil))
+	}
+
+	empty := gwc.CreateGateway("empty").CreateRoute("route")
+	assert.DeepEqual(t, DiffPolicies(cache.MergedPolicies(empty), empty.MergedPolicies(AuthPolicyMerger)), []FieldDiff(nil))
+}
+
+func TestMergeCache_Invalidation(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	other := gwc.CreateGateway("other")
+	route := gw.CreateRoute("route")
+	otherRoute := other.CreateRoute("route")
+
+	gwDefault := 42
+	classOverride := 420
+	routeDefault := 1
+	enabled := true
+
+	cache := NewMergeCache(FakePolicyMerger)
+	assert.Check(t, cache.MergedPolicies(route).value == nil)
+
+	gw.AddPolicy(PolicySpec[FakePolicy]{name: "gw", defaults: FakePolicy{value: &gwDefault}})
+	assert.Equal(t, *cache.MergedPolicies(route).value, 42)
+	assert.Check(t, cache.MergedPolicies(otherRoute).value == nil)
+	assert.Equal(t, cache.gateways[gw].generation, uint64(1))
+
+	route.AddPolicy(PolicySpec[FakePolicy]{name: "route", defaults: FakePolicy{value: &routeDefault}})
+	assert.Equal(t, *cache.MergedPolicies(route).value, 1)
+
+	gwc.AddPolicy(PolicySpec[FakePolicy]{
+		name:      "class",
+		overrides: FakePolicy{value: &classOverride, enabled: &enabled},
+	})
+	assert.Equal(t, *cache.MergedPolicies(route).value, 420)
+	assert.Equal(t, *cache.MergedPolicies(otherRoute).value, 420)
+	assert.Check(t, *cache.MergedPolicies(otherRoute).enabled)
+	assert.Equal(t, cache.gateways[gw].parentGeneration, uint64(1))
+}
+
+func benchmarkHierarchy(routes int) (*GatewayClass[AuthPolicy], []*HttpRoute[AuthPolicy]) {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	for i := 0; i < 10; i++ {
+		identity := AuthPolicy{
+			Identity: []*authorino.Identity{{Name: fmt.Sprintf("identity-%d", i), Anonymous: &authorino.Identity_Anonymous{}}},
+		}
+		gwc.AddPolicy(PolicySpec[AuthPolicy]{name: fmt.Sprintf("class-%d", i), defaults: identity, overrides: testAuthPolicySpec2})
+		gw.AddPolicy(PolicySpec[AuthPolicy]{name: fmt.Sprintf("gw-%d", i), defaults: testAuthPolicySpec1, overrides: identity})
+	}
+
+	var all []*HttpRoute[AuthPolicy]
+	for i := 0; i < routes; i++ {
+		route := gw.CreateRoute(fmt.Sprintf("route-%d", i))
+		route.AddPolicy(PolicySpec[AuthPolicy]{name: "route", defaults: testAuthPolicySpec1})
+		all = append(all, route)
+	}
+	return &gwc, all
+}
+
+func BenchmarkMergedPolicies(b *testing.B) {
+	_, routes := benchmarkHierarchy(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, route := range routes {
+			route.MergedPolicies(AuthPolicyMerger)
+		}
+	}
+}
+
+func BenchmarkMergeCache(b *testing.B) {
+	_, routes := benchmarkHierarchy(1000)
+	cache := NewMergeCache(AuthPolicyMerger)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, route := range routes {
+			cache.MergedPolicies(route)
+		}
+	}
+}
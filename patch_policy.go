@@ -0,0 +1,83 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+)
+
+type PatchType string
+
+const (
+	// MergePatchType is an RFC 7386 JSON Merge Patch: objects are merged recursively, `null` removes a field, and
+	// anything else, arrays included, replaces the inherited value.
+	MergePatchType PatchType = "merge"
+
+	// JSONPatchType is an RFC 6902 JSON Patch: a list of operations (add, remove, replace, move, copy, test) on paths of
+	// the inherited document.
+	JSONPatchType PatchType = "json"
+)
+
+type Patch struct {
+	Type  PatchType       `json:"type"`
+	Patch json.RawMessage `json:"patch"`
+}
+
+// PatchPolicy expresses a default or an override as patches to the policy document inherited from the lower precedence
+// policies, instead of a typed value combined by a merger. It lets the patch based semantics be compared with the ones
+// of a typed merger, e.g. AuthPolicyMerger, on the same scenarios.
+type PatchPolicy struct {
+	// Patches are applied in order, each to the result of the previous ones: the last one has the highest precedence.
+	Patches []Patch `json:"patches,omitempty"`
+}
+
+func NewMergePatchPolicy(patch string) PatchPolicy {
+	return PatchPolicy{Patches: []Patch{{Type: MergePatchType, Patch: json.RawMessage(patch)}}}
+}
+
+func NewJSONPatchPolicy(patch string) PatchPolicy {
+	return PatchPolicy{Patches: []Patch{{Type: JSONPatchType, Patch: json.RawMessage(patch)}}}
+}
+
+// PatchPolicyMerger has the patches of p1, the higher precedence policy, applied after the ones of p2. Nothing is
+// applied while merging: a patch failing, e.g. a JSON Patch on a path that doesn't exist, only does so in Apply.
+func PatchPolicyMerger(p1, p2 PatchPolicy) PatchPolicy {
+	var patches []Patch
+	patches = append(patches, p2.Patches...)
+	patches = append(patches, p1.Patches...)
+	return PatchPolicy{Patches: patches}
+}
+
+// Apply applies the patches to the JSON document.
+func (p PatchPolicy) Apply(document []byte) ([]byte, error) {
+	for i, patch := range p.Patches {
+		var err error
+		switch patch.Type {
+		case MergePatchType:
+			document, err = jsonpatch.MergePatch(document, patch.Patch)
+		case JSONPatchType:
+			var operations jsonpatch.Patch
+			if operations, err = jsonpatch.DecodePatch(patch.Patch); err == nil {
+				document, err = operations.Apply(document)
+			}
+		default:
+			err = fmt.Errorf("unknown patch type %q", patch.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i, err)
+		}
+	}
+	return document, nil
+}
+
+// PatchedPolicy applies the patches to an empty document and decodes the result as a T.
+func PatchedPolicy[T Policy](p PatchPolicy) (T, error) {
+	var policy T
+	document, err := p.Apply([]byte(`{}`))
+	if err != nil {
+		return policy, err
+	}
+	err = json.Unmarshal(document, &policy)
+	return policy, err
+}
@@ -0,0 +1,67 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+func TestPatchPolicy_MergePatch(t *testing.T) {
+	gwc := NewGatewayClass[PatchPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	gw.AddPolicy(NewPolicySpec("gw",
+		NewMergePatchPolicy(`{"identity":[{"name":"friends","anonymous":{}}],"when":[{"selector":"context.request.http.method","operator":"eq","value":"GET"}]}`),
+		NewMergePatchPolicy(`{"denyWith":{"unauthenticated":{"code":401}}}`),
+	))
+	route.AddPolicy(NewPolicySpec("route",
+		NewMergePatchPolicy(`{"identity":[{"name":"keys","apiKey":{"selector":{}}}],"when":null}`),
+		NewMergePatchPolicy(`{"denyWith":{"unauthenticated":{"code":302}}}`),
+	))
+
+	policy, err := PatchedPolicy[AuthPolicy](route.MergedPolicies(PatchPolicyMerger))
+	assert.NilError(t, err)
+
+	// Arrays are replaced, rather than merged by name, and `null` removes what's inherited.
+	assert.Equal(t, len(policy.Identity), 1)
+	assert.Equal(t, policy.Identity[0].Name, "keys")
+	assert.Equal(t, len(policy.Conditions), 0)
+	assert.Equal(t, int(policy.DenyWith.Unauthenticated.Code), 401)
+
+	typed := NewGatewayClass[AuthPolicy]("gwc1")
+	typedRoute := typed.CreateGateway("gw").CreateRoute("route")
+	typedRoute.AddPolicy(NewPolicySpec("route", AuthPolicy{
+		Identity: []*authorino.Identity{{Name: "keys", APIKey: &authorino.Identity_APIKey{}}},
+	}, AuthPolicy{}))
+	typedRoute.parent.AddPolicy(NewPolicySpec("gw", AuthPolicy{
+		Identity: []*authorino.Identity{{Name: "friends", Anonymous: &authorino.Identity_Anonymous{}}},
+	}, AuthPolicy{}))
+	assert.Equal(t, len(typedRoute.MergedPolicies(AuthPolicyMerger).Identity), 2)
+}
+
+func TestPatchPolicy_JSONPatch(t *testing.T) {
+	gwc := NewGatewayClass[PatchPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	gw.AddPolicy(NewPolicySpec("gw", NewMergePatchPolicy(`{"identity":[{"name":"friends","anonymous":{}}]}`), PatchPolicy{}))
+	route.AddPolicy(NewPolicySpec("route", NewJSONPatchPolicy(`[{"op":"add","path":"/identity/-","value":{"name":"keys","apiKey":{"selector":{}}}}]`), PatchPolicy{}))
+
+	// The gateway's default is inherited by the route's, which can then append to it.
+	policy, err := PatchedPolicy[AuthPolicy](route.MergedPolicies(PatchPolicyMerger))
+	assert.NilError(t, err)
+	assert.Equal(t, len(policy.Identity), 2)
+	assert.Equal(t, policy.Identity[0].Name, "friends")
+	assert.Equal(t, policy.Identity[1].Name, "keys")
+
+	other := gw.CreateRoute("other")
+	other.AddPolicy(NewPolicySpec("other", NewJSONPatchPolicy(`[{"op":"remove","path":"/metadata"}]`), PatchPolicy{}))
+	_, err = PatchedPolicy[AuthPolicy](other.MergedPolicies(PatchPolicyMerger))
+	assert.ErrorContains(t, err, "patch 1:")
+
+	_, err = PatchedPolicy[AuthPolicy](PatchPolicy{Patches: []Patch{{Type: "strategic", Patch: []byte(`{}`)}}})
+	assert.ErrorContains(t, err, `unknown patch type "strategic"`)
+}
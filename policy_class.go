@@ -0,0 +1,83 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PolicyClass tells whether a policy only affects the object it targets, or the objects beneath it too, as per
+// GEP-2648.
+type PolicyClass string
+
+const (
+	// DirectPolicyClass policies only affect the object they target: attached to a Gateway, they're not part of the
+	// effective policy of its routes.
+	DirectPolicyClass PolicyClass = "Direct"
+
+	// InheritedPolicyClass policies affect the object they target and all the objects beneath it. It's the default.
+	InheritedPolicyClass PolicyClass = "Inherited"
+
+	// PolicyClassLabel is the label of the GEP, set here on a policy CR rather than on its CRD, to classify a policy on
+	// its own.
+	PolicyClassLabel = "gateway.networking.k8s.io/policy"
+)
+
+func (c PolicyClass) valid() bool {
+	return c == DirectPolicyClass || c == InheritedPolicyClass
+}
+
+// UnmarshalJSON rejects any class but the ones of the GEP. An empty class stands for the one of the policy type.
+func (c *PolicyClass) UnmarshalJSON(data []byte) error {
+	var class string
+	if err := json.Unmarshal(data, &class); err != nil {
+		return err
+	}
+	if class != "" && !PolicyClass(class).valid() {
+		return fmt.Errorf("unknown policy class %q", class)
+	}
+	*c = PolicyClass(class)
+	return nil
+}
+
+// PolicyClassifier is implemented by the policy types that aren't all Inherited, e.g. a policy configuring the
+// listeners of a Gateway, which has nothing to do on its routes.
+type PolicyClassifier interface {
+	PolicyClass() PolicyClass
+}
+
+func policyTypeClass[T Policy]() PolicyClass {
+	var policy T
+	if classifier, ok := interface{}(policy).(PolicyClassifier); ok && classifier.PolicyClass() != "" {
+		return classifier.PolicyClass()
+	}
+	return InheritedPolicyClass
+}
+
+// WithClass classifies the policy on its own, regardless of the class of its type. An unknown class is ignored, as is
+// the label of a policy CR then.
+func (p PolicySpec[T]) WithClass(class PolicyClass) PolicySpec[T] {
+	if class.valid() {
+		p.class = class
+	}
+	return p
+}
+
+// Class is the class of the policy, if set, the one of its type otherwise.
+func (p PolicySpec[T]) Class() PolicyClass {
+	if p.class != "" {
+		return p.class
+	}
+	return policyTypeClass[T]()
+}
+
+// inheritedContributions drops the Direct policies attached to other targets than object, i.e. to its ancestors.
+func inheritedContributions[T Policy](object interface{}, contributions []policyContribution[T]) []policyContribution[T] {
+	var inherited []policyContribution[T]
+	for _, contribution := range contributions {
+		if contribution.direct && contribution.target != object {
+			continue
+		}
+		inherited = append(inherited, contribution)
+	}
+	return inherited
+}
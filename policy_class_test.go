@@ -0,0 +1,74 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DirectFakePolicy is a FakePolicy whose type is Direct.
+type DirectFakePolicy FakePolicy
+
+func (DirectFakePolicy) PolicyClass() PolicyClass { return DirectPolicyClass }
+
+func TestPolicyClass_Merge(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	one, two, three := 1, 2, 3
+	enabled := true
+	gwc.AddPolicy(NewPolicySpec("class", FakePolicy{enabled: &enabled}, FakePolicy{}))
+	gw.AddPolicy(NewPolicySpec("gw", FakePolicy{}, FakePolicy{value: &one}).WithClass(DirectPolicyClass))
+	route.AddPolicy(NewPolicySpec("route", FakePolicy{value: &two}, FakePolicy{}))
+
+	result := route.MergedPolicies(FakePolicyMerger)
+	assert.Equal(t, *result.value, 2)
+	assert.Check(t, *result.enabled)
+	assert.Equal(t, *NewMergeCache(FakePolicyMerger).MergedPolicies(route).value, 2)
+
+	route.AddPolicy(NewPolicySpec("route-direct", FakePolicy{}, FakePolicy{value: &three}).WithClass(DirectPolicyClass))
+	assert.Equal(t, *route.MergedPolicies(FakePolicyMerger).value, 3)
+
+	assert.DeepEqual(t, route.AffectingPolicies(FakePolicyMerger), []AffectingPolicy{
+		{Name: "gw", TargetKind: "Gateway", TargetName: "gw", Inherited: true, Class: DirectPolicyClass, Participation: ParticipationNotInherited},
+		{Name: "route-direct", TargetKind: "HTTPRoute", TargetName: "route", Class: DirectPolicyClass, Participation: ParticipationOverride},
+		{Name: "route", TargetKind: "HTTPRoute", TargetName: "route", Class: InheritedPolicyClass, Participation: ParticipationShadowed},
+		{Name: "class", TargetKind: "GatewayClass", TargetName: "gwc1", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationDefault},
+	})
+	assert.DeepEqual(t, gw.AffectingPolicies(FakePolicyMerger)[0],
+		AffectingPolicy{Name: "gw", TargetKind: "Gateway", TargetName: "gw", Class: DirectPolicyClass, Participation: ParticipationOverride})
+}
+
+func TestPolicyClass_PolicyType(t *testing.T) {
+	gwc := NewGatewayClass[DirectFakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	one, two := 1, 2
+	gw.AddPolicy(NewPolicySpec("gw", DirectFakePolicy{value: &one}, DirectFakePolicy{}))
+	merger := func(p1, p2 DirectFakePolicy) DirectFakePolicy {
+		return DirectFakePolicy(FakePolicyMerger(FakePolicy(p1), FakePolicy(p2)))
+	}
+	assert.Check(t, route.MergedPolicies(merger).value == nil)
+
+	gw.AddPolicy(NewPolicySpec("gw-inherited", DirectFakePolicy{value: &two}, DirectFakePolicy{}).WithClass(InheritedPolicyClass))
+	assert.Equal(t, *route.MergedPolicies(merger).value, 2)
+
+	assert.Equal(t, NewPolicySpec("gw-unknown", DirectFakePolicy{}, DirectFakePolicy{}).WithClass("Sideways").Class(), DirectPolicyClass)
+}
+
+func TestPolicyStatus_Direct(t *testing.T) {
+	policy := newTestAuthPolicyCR("gateway-system", "gw-policy", "Gateway", "gw", &testAuthPolicySpec1, nil, 0)
+	policy.Labels = map[string]string{PolicyClassLabel: string(DirectPolicyClass)}
+	statuses := testStatusTopology(policy)
+
+	status := statuses[types.NamespacedName{Namespace: "gateway-system", Name: "gw-policy"}]
+	accepted := condition(t, status, PolicyConditionAccepted)
+	assert.Equal(t, accepted.Status, metav1.ConditionTrue)
+	assert.Equal(t, accepted.Reason, PolicyReasonAccepted)
+	assert.Equal(t, accepted.Message, "Policy has been accepted, it's Direct and only affects its target, not the routes beneath it")
+	assert.Equal(t, len(status.Ancestors[0].Conditions), 1)
+}
@@ -0,0 +1,175 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const KuadrantGroup = "kuadrant.io"
+
+// PolicyGroupVersion is the group version the policy CRs are registered with in a runtime.Scheme.
+var PolicyGroupVersion = schema.GroupVersion{Group: KuadrantGroup, Version: "v1beta2"}
+
+// PolicyTargetReference identifies the Gateway API object a policy CR attaches to.
+type PolicyTargetReference struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// PolicyCR is a Kuadrant-style policy custom resource, e.g. an `AuthPolicy` or a `RateLimitPolicy`.
+type PolicyCR[T Policy] struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolicyCRSpec[T] `json:"spec"`
+	Status PolicyStatus    `json:"status,omitempty"`
+}
+
+type PolicyCRSpec[T Policy] struct {
+	// TargetRef identifies an API object to apply the policy to.
+	TargetRef PolicyTargetReference `json:"targetRef"`
+
+	// Defaults are the policy rules that lower levels of the hierarchy can replace.
+	// A bare spec, i.e. the rules set directly under `spec`, are implicit defaults.
+	Defaults *T `json:"defaults,omitempty"`
+
+	// Overrides are the policy rules that win over the ones of the lower levels of the hierarchy.
+	Overrides *T `json:"overrides,omitempty"`
+}
+
+func (s *PolicyCRSpec[T]) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var spec struct {
+		TargetRef PolicyTargetReference `json:"targetRef"`
+		Defaults  *T                    `json:"defaults,omitempty"`
+		Overrides *T                    `json:"overrides,omitempty"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+	delete(fields, "targetRef")
+	delete(fields, "defaults")
+	delete(fields, "overrides")
+
+	if len(fields) > 0 {
+		if spec.Defaults != nil || spec.Overrides != nil {
+			bare := make([]string, 0, len(fields))
+			for name := range fields {
+				bare = append(bare, name)
+			}
+			sort.Strings(bare)
+			return fmt.Errorf("spec %v cannot be mixed with explicit defaults or overrides", bare)
+		}
+		implicit, _ := json.Marshal(fields)
+		spec.Defaults = new(T)
+		if err := json.Unmarshal(implicit, spec.Defaults); err != nil {
+			return err
+		}
+	}
+
+	*s = PolicyCRSpec[T](spec)
+	return nil
+}
+
+type PolicyCRList[T Policy] struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PolicyCR[T] `json:"items"`
+}
+
+// AddToScheme registers the `AuthPolicy` and `RateLimitPolicy` CRs, e.g. for a controller-runtime client.
+func AddToScheme(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypeWithName(PolicyGroupVersion.WithKind("AuthPolicy"), &PolicyCR[AuthPolicy]{})
+	scheme.AddKnownTypeWithName(PolicyGroupVersion.WithKind("AuthPolicyList"), &PolicyCRList[AuthPolicy]{})
+	scheme.AddKnownTypeWithName(PolicyGroupVersion.WithKind("RateLimitPolicy"), &PolicyCR[RateLimitPolicy]{})
+	scheme.AddKnownTypeWithName(PolicyGroupVersion.WithKind("RateLimitPolicyList"), &PolicyCRList[RateLimitPolicy]{})
+	metav1.AddToGroupVersion(scheme, PolicyGroupVersion)
+	return nil
+}
+
+func (cr *PolicyCR[T]) DeepCopyObject() runtime.Object {
+	out := &PolicyCR[T]{TypeMeta: cr.TypeMeta}
+	cr.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = deepCopySpec(cr.Spec)
+	out.Status = cr.Status.DeepCopy()
+	return out
+}
+
+func (l *PolicyCRList[T]) DeepCopyObject() runtime.Object {
+	out := &PolicyCRList[T]{TypeMeta: l.TypeMeta}
+	l.ListMeta.DeepCopyInto(&out.ListMeta)
+	if l.Items != nil {
+		out.Items = make([]PolicyCR[T], len(l.Items))
+		for i := range l.Items {
+			out.Items[i] = *l.Items[i].DeepCopyObject().(*PolicyCR[T])
+		}
+	}
+	return out
+}
+
+// deepCopySpec copies the spec through its JSON representation, as the policy types have no generated deep copy.
+func deepCopySpec[T Policy](spec PolicyCRSpec[T]) PolicyCRSpec[T] {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		panic(fmt.Sprintf("policy spec can't be copied: %v", err))
+	}
+	var out PolicyCRSpec[T]
+	if err := json.Unmarshal(raw, &out); err != nil {
+		panic(fmt.Sprintf("policy spec can't be copied: %v", err))
+	}
+	return out
+}
+
+// PolicySpec returns what the CR contributes to the hierarchy, once attached to its target.
+func (cr *PolicyCR[T]) PolicySpec() PolicySpec[T] {
+	spec := PolicySpec[T]{name: cr.Name}
+	if cr.Spec.Defaults != nil {
+		spec.defaults = *cr.Spec.Defaults
+	}
+	if cr.Spec.Overrides != nil {
+		spec.overrides = *cr.Spec.Overrides
+	}
+	if class := PolicyClass(cr.Labels[PolicyClassLabel]); class.valid() {
+		spec.class = class
+	}
+	return spec
+}
+
+func DecodeAuthPolicy(data []byte) (*PolicyCR[AuthPolicy], error) {
+	return decodePolicyCR[AuthPolicy](data, "AuthPolicy")
+}
+
+func DecodeRateLimitPolicy(data []byte) (*PolicyCR[RateLimitPolicy], error) {
+	return decodePolicyCR[RateLimitPolicy](data, "RateLimitPolicy")
+}
+
+func decodePolicyCR[T Policy](data []byte, kind string) (*PolicyCR[T], error) {
+	raw, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var cr PolicyCR[T]
+	if err := json.Unmarshal(raw, &cr); err != nil {
+		return nil, fmt.Errorf("%s: %w", kind, err)
+	}
+	if gvk := cr.GroupVersionKind(); gvk.Group != KuadrantGroup || gvk.Kind != kind {
+		return nil, fmt.Errorf("expected a %s.%s, got %q %q", kind, KuadrantGroup, cr.APIVersion, cr.Kind)
+	}
+	if cr.Spec.TargetRef.Kind == "" || cr.Spec.TargetRef.Name == "" {
+		return nil, fmt.Errorf("%s %q: spec.targetRef requires a kind and a name", kind, cr.Name)
+	}
+	return &cr, nil
+}
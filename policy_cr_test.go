@@ -0,0 +1,165 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const testAuthPolicyCR = `
+apiVersion: kuadrant.io/v1beta2
+kind: AuthPolicy
+metadata:
+  name: toystore
+  namespace: toystore
+spec:
+  targetRef:
+    group: gateway.networking.k8s.io
+    kind: HTTPRoute
+    name: toystore
+  defaults:
+    identity:
+    - name: friends
+      anonymous: {}
+  overrides:
+    identity:
+    - name: friends
+      apiKey:
+        selector:
+          matchLabels:
+            my-app.io/group: friends
+    denyWith:
+      unauthorized:
+        code: 302
+`
+
+func TestDecodeAuthPolicy_DefaultsAndOverrides(t *testing.T) {
+	cr, err := DecodeAuthPolicy([]byte(testAuthPolicyCR))
+	assert.NilError(t, err)
+
+	assert.Equal(t, cr.Name, "toystore")
+	assert.Equal(t, cr.Namespace, "toystore")
+	assert.Equal(t, cr.Spec.TargetRef, PolicyTargetReference{
+		Group: "gateway.networking.k8s.io",
+		Kind:  "HTTPRoute",
+		Name:  "toystore",
+	})
+	assert.Check(t, cr.Spec.Defaults.Identity[0].Anonymous != nil)
+	assert.Check(t, cr.Spec.Overrides.Identity[0].APIKey != nil)
+
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	route := gwc.CreateGateway("gw").CreateRoute("toystore")
+	route.AddPolicy(cr.PolicySpec())
+
+	result := route.MergedPolicies(AuthPolicyMerger)
+
+	assert.Equal(t, len(result.Identity), 1)
+	assert.Check(t, result.Identity[0].APIKey != nil)
+	assert.Equal(t, int(result.DenyWith.Unauthorized.Code), 302)
+}
+
+func TestDecodeAuthPolicy_ImplicitDefaults(t *testing.T) {
+	cr, err := DecodeAuthPolicy([]byte(`
+apiVersion: kuadrant.io/v1beta2
+kind: AuthPolicy
+metadata:
+  name: gateway-auth
+spec:
+  targetRef:
+    group: gateway.networking.k8s.io
+    kind: Gateway
+    name: gw
+  identity:
+  - name: anonymous
+    $remove: true
+  authorization:
+  - name: my-policy
+    opa:
+      inlineRego: allow = true
+`))
+	assert.NilError(t, err)
+
+	assert.Check(t, cr.Spec.Overrides == nil)
+	assert.Equal(t, len(cr.Spec.Defaults.Authorization), 1)
+	assert.DeepEqual(t, cr.Spec.Defaults.Removals, []string{"identity.anonymous"})
+
+	spec := cr.PolicySpec()
+	assert.Equal(t, spec.name, "gateway-auth")
+	assert.Equal(t, spec.defaults.Authorization[0].Name, "my-policy")
+	assert.Equal(t, len(spec.overrides.Authorization), 0)
+}
+
+func TestDecodeAuthPolicy_MixedSpec(t *testing.T) {
+	_, err := DecodeAuthPolicy([]byte(`
+apiVersion: kuadrant.io/v1beta2
+kind: AuthPolicy
+metadata:
+  name: mixed
+spec:
+  targetRef:
+    group: gateway.networking.k8s.io
+    kind: Gateway
+    name: gw
+  identity:
+  - name: friends
+    anonymous: {}
+  overrides:
+    denyWith:
+      unauthorized:
+        code: 302
+`))
+	assert.ErrorContains(t, err, "spec [identity] cannot be mixed with explicit defaults or overrides")
+}
+
+func TestDecodeAuthPolicy_Invalid(t *testing.T) {
+	_, err := DecodeAuthPolicy([]byte(`
+apiVersion: kuadrant.io/v1beta2
+kind: RateLimitPolicy
+metadata:
+  name: wrong-kind
+spec:
+  targetRef:
+    group: gateway.networking.k8s.io
+    kind: Gateway
+    name: gw
+`))
+	assert.ErrorContains(t, err, "expected a AuthPolicy.kuadrant.io")
+
+	_, err = DecodeAuthPolicy([]byte(`
+apiVersion: kuadrant.io/v1beta2
+kind: AuthPolicy
+metadata:
+  name: no-target
+spec:
+  identity: []
+`))
+	assert.ErrorContains(t, err, "spec.targetRef requires a kind and a name")
+}
+
+func TestDecodeRateLimitPolicy(t *testing.T) {
+	cr, err := DecodeRateLimitPolicy([]byte(`
+apiVersion: kuadrant.io/v1beta2
+kind: RateLimitPolicy
+metadata:
+  name: toystore
+spec:
+  targetRef:
+    group: gateway.networking.k8s.io
+    kind: HTTPRoute
+    name: toystore
+  limits:
+    per-user:
+      counters:
+      - auth.identity.username
+      rates:
+      - limit: 10
+        duration: 1
+        unit: minute
+`))
+	assert.NilError(t, err)
+
+	assert.Check(t, cr.Spec.Overrides == nil)
+	limit := cr.Spec.Defaults.Limits["per-user"]
+	assert.DeepEqual(t, limit.Counters, []ContextSelector{"auth.identity.username"})
+	assert.DeepEqual(t, limit.Rates, []Rate{{Limit: 10, Duration: 1, Unit: MinuteTimeUnit}})
+}
@@ -0,0 +1,269 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldDiff is a single leaf value that differs between two policies, addressed by its path (e.g.
+// `identity[friends].apiKey`).
+type FieldDiff struct {
+	Path   string `json:"path"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type RouteRef struct {
+	Gateway string `json:"gateway"`
+	Route   string `json:"route"`
+
+	// Kind is empty for HTTPRoutes, and set for routes of any other kind.
+	Kind RouteKind `json:"kind,omitempty"`
+}
+
+func (ref RouteRef) String() string {
+	if ref.Kind != "" {
+		return fmt.Sprintf("%s %s/%s", ref.Kind, ref.Gateway, ref.Route)
+	}
+	return fmt.Sprintf("%s/%s", ref.Gateway, ref.Route)
+}
+
+func routeRefOf[T Policy](route routeNode[T]) RouteRef {
+	return RouteRef{Gateway: route.gateway().name, Route: route.routeName(), Kind: route.kind()}.normalized()
+}
+
+// routeKind is the Kind of the route referred to, HTTPRoute if empty.
+func (ref RouteRef) routeKind() RouteKind {
+	if ref.Kind == "" {
+		return HTTPRouteKind
+	}
+	return ref.Kind
+}
+
+// normalized leaves the Kind out of a reference to an HTTPRoute, so that both forms compare equal.
+func (ref RouteRef) normalized() RouteRef {
+	if ref.Kind == HTTPRouteKind {
+		ref.Kind = ""
+	}
+	return ref
+}
+
+type RouteDiff struct {
+	RouteRef
+	Changes []FieldDiff `json:"changes"`
+}
+
+// HierarchyDiff is the blast radius of going from one hierarchy snapshot to another, route by route.
+type HierarchyDiff struct {
+	Changed   []RouteDiff `json:"changed,omitempty"`
+	Unchanged []RouteRef  `json:"unchanged,omitempty"`
+	Added     []RouteRef  `json:"added,omitempty"`
+	Removed   []RouteRef  `json:"removed,omitempty"`
+}
+
+func (d HierarchyDiff) Empty() bool {
+	return len(d.Changed) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffEffectivePolicies computes the MergedPolicies of every route in both snapshots, of all kinds, and reports, per
+// route, the fields of the effective policy that changed. Routes are matched by gateway, kind and route name.
+func DiffEffectivePolicies[T Policy](before, after *GatewayClass[T], merger func(T, T) T) HierarchyDiff {
+	old := effectivePolicies(before, merger)
+	updated := effectivePolicies(after, merger)
+
+	var diff HierarchyDiff
+	for _, ref := range sortedRefs(old) {
+		if _, exists := updated[ref]; !exists {
+			diff.Removed = append(diff.Removed, ref)
+			continue
+		}
+		changes := DiffPolicies(old[ref], updated[ref])
+		if len(changes) == 0 {
+			diff.Unchanged = append(diff.Unchanged, ref)
+		} else {
+			diff.Changed = append(diff.Changed, RouteDiff{RouteRef: ref, Changes: changes})
+		}
+	}
+	for _, ref := range sortedRefs(updated) {
+		if _, exists := old[ref]; !exists {
+			diff.Added = append(diff.Added, ref)
+		}
+	}
+	return diff
+}
+
+// DiffPolicies returns the field-level differences between two policies. Nil and empty maps or slices are considered
+// equal, as they are for the mergers.
+func DiffPolicies[T Policy](before, after T) []FieldDiff {
+	var diffs []FieldDiff
+	diffValues("", reflect.ValueOf(&before).Elem(), reflect.ValueOf(&after).Elem(), &diffs)
+	return diffs
+}
+
+func effectivePolicies[T Policy](gwc *GatewayClass[T], merger func(T, T) T) map[RouteRef]T {
+	gwc.lock.RLock()
+	defer gwc.lock.RUnlock()
+
+	routes := make(map[RouteRef]T)
+	for _, route := range routesBeneath[T](gwc) {
+		routes[routeRefOf(route)] = foldContributions(applicableContributions(route), merger)
+	}
+	return routes
+}
+
+func sortedRefs[T Policy](routes map[RouteRef]T) []RouteRef {
+	refs := make([]RouteRef, 0, len(routes))
+	for ref := range routes {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].String() < refs[j].String()
+	})
+	return refs
+}
+
+func diffValues(path string, a, b reflect.Value, diffs *[]FieldDiff) {
+	if isEmpty(a) && isEmpty(b) {
+		return
+	}
+	if isEmpty(a) || isEmpty(b) {
+		*diffs = append(*diffs, FieldDiff{Path: path, Before: formatValue(a), After: formatValue(b)})
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.Elem().Type() != b.Elem().Type() {
+			*diffs = append(*diffs, FieldDiff{Path: path, Before: formatValue(a), After: formatValue(b)})
+			return
+		}
+		diffValues(path, a.Elem(), b.Elem(), diffs)
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			diffValues(joinPath(path, fieldName(a.Type().Field(i))), a.Field(i), b.Field(i), diffs)
+		}
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, key := range append(a.MapKeys(), b.MapKeys()...) {
+			keys[fmt.Sprint(key)] = key
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			diffValues(fmt.Sprintf("%s[%s]", path, name), a.MapIndex(keys[name]), b.MapIndex(keys[name]), diffs)
+		}
+	case reflect.Slice, reflect.Array:
+		if a.Type().Elem().Kind() == reflect.Uint8 {
+			if !reflect.DeepEqual(a.Bytes(), b.Bytes()) {
+				*diffs = append(*diffs, FieldDiff{Path: path, Before: formatValue(a), After: formatValue(b)})
+			}
+			return
+		}
+		if x, xNames, ok := namedElements(a); ok {
+			if y, yNames, ok := namedElements(b); ok {
+				for _, name := range append(xNames, yNames...) {
+					if _, exists := x[name]; !exists {
+						if _, exists := y[name]; !exists {
+							continue
+						}
+					}
+					diffValues(fmt.Sprintf("%s[%s]", path, name), x[name], y[name], diffs)
+					delete(x, name)
+					delete(y, name)
+				}
+				return
+			}
+		}
+		for i := 0; i < a.Len() || i < b.Len(); i++ {
+			var x, y reflect.Value
+			if i < a.Len() {
+				x = a.Index(i)
+			}
+			if i < b.Len() {
+				y = b.Index(i)
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), x, y, diffs)
+		}
+	default:
+		if before, after := formatValue(a), formatValue(b); before != after {
+			*diffs = append(*diffs, FieldDiff{Path: path, Before: before, After: after})
+		}
+	}
+}
+
+// namedElements indexes the elements of a list of (pointers to) structs by their `Name`, if they all have a distinct
+// one, so that named entries are compared regardless of their position.
+func namedElements(list reflect.Value) (map[string]reflect.Value, []string, bool) {
+	elements := make(map[string]reflect.Value, list.Len())
+	names := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		element := list.Index(i)
+		for element.Kind() == reflect.Ptr && !element.IsNil() {
+			element = element.Elem()
+		}
+		if element.Kind() != reflect.Struct {
+			return nil, nil, false
+		}
+		field := element.FieldByName("Name")
+		if !field.IsValid() || field.Kind() != reflect.String || field.String() == "" {
+			return nil, nil, false
+		}
+		if _, exists := elements[field.String()]; exists {
+			return nil, nil, false
+		}
+		elements[field.String()] = list.Index(i)
+		names = append(names, field.String())
+	}
+	return elements, names, true
+}
+
+func isEmpty(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return false
+}
+
+func formatValue(v reflect.Value) string {
+	if isEmpty(v) {
+		return "<nil>"
+	}
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		return formatValue(v.Elem())
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return string(v.Bytes())
+	}
+	if v.CanInterface() {
+		if raw, err := json.Marshal(v.Interface()); err == nil {
+			return string(raw)
+		}
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+func fieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,140 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+)
+
+func testDiffHierarchy(classOverride AuthPolicy) *GatewayClass[AuthPolicy] {
+	gwc := NewGatewayClass[AuthPolicy]("gwc1")
+	gwc.AddPolicy(PolicySpec[AuthPolicy]{
+		name:      "cluster-policy",
+		overrides: classOverride,
+	})
+
+	gw := gwc.CreateGateway("gw")
+	gw.CreateRoute("bare")
+
+	api := gw.CreateRoute("api")
+	api.AddPolicy(PolicySpec[AuthPolicy]{
+		name:     "api-policy",
+		defaults: testAuthPolicySpec1,
+	})
+
+	internal := gw.CreateRoute("internal")
+	internal.AddPolicy(PolicySpec[AuthPolicy]{
+		name:      "internal-policy",
+		overrides: testAuthPolicySpec2,
+	})
+	return &gwc
+}
+
+func TestDiff_ClassOverride(t *testing.T) {
+	before := testDiffHierarchy(AuthPolicy{})
+	after := testDiffHierarchy(AuthPolicy{
+		Identity: []*authorino.Identity{
+			{
+				Name: "friends",
+				Oidc: &authorino.Identity_OidcConfig{Endpoint: "https://sso.my-company.io"},
+			},
+		},
+	})
+
+	diff := DiffEffectivePolicies(before, after, AuthPolicyMerger)
+
+	assert.Equal(t, len(diff.Changed), 3)
+	assert.Equal(t, len(diff.Unchanged), 0)
+	assert.Check(t, !diff.Empty())
+
+	api := diff.Changed[0]
+	assert.Equal(t, api.RouteRef, RouteRef{Gateway: "gw", Route: "api"})
+	assert.DeepEqual(t, api.Changes, []FieldDiff{
+		{Path: "identity[friends].oidc", Before: "<nil>", After: `{"endpoint":"https://sso.my-company.io"}`},
+		{Path: "identity[friends].anonymous", Before: "{}", After: "<nil>"},
+	})
+
+	bare := diff.Changed[1]
+	assert.Equal(t, bare.RouteRef, RouteRef{Gateway: "gw", Route: "bare"})
+	assert.Equal(t, len(bare.Changes), 1)
+	assert.Equal(t, bare.Changes[0].Path, "identity")
+}
+
+func TestDiff_UnchangedAddedRemoved(t *testing.T) {
+	before := testDiffHierarchy(AuthPolicy{})
+	after := testDiffHierarchy(AuthPolicy{})
+	for gw := range after.gateways {
+		for route := range gw.routes {
+			if route.name == "internal" {
+				delete(gw.routes, route)
+			}
+		}
+		gw.CreateRoute("new")
+	}
+
+	diff := DiffEffectivePolicies(before, after, AuthPolicyMerger)
+
+	assert.Check(t, !diff.Empty())
+	assert.Equal(t, len(diff.Changed), 0)
+	assert.DeepEqual(t, diff.Unchanged, []RouteRef{{Gateway: "gw", Route: "api"}, {Gateway: "gw", Route: "bare"}})
+	assert.DeepEqual(t, diff.Added, []RouteRef{{Gateway: "gw", Route: "new"}})
+	assert.DeepEqual(t, diff.Removed, []RouteRef{{Gateway: "gw", Route: "internal"}})
+}
+
+func TestDiff_RouteKinds(t *testing.T) {
+	hierarchy := func(classOverride AuthPolicy) *GatewayClass[AuthPolicy] {
+		gwc := testDiffHierarchy(classOverride)
+		for gw := range gwc.gateways {
+			gw.CreateGrpcRoute("api")
+			gw.CreateTcpRoute("api")
+		}
+		return gwc
+	}
+	before := hierarchy(AuthPolicy{})
+	after := hierarchy(AuthPolicy{Identity: []*authorino.Identity{{Name: "friends", Anonymous: &authorino.Identity_Anonymous{}}}})
+
+	diff := DiffEffectivePolicies(before, after, AuthPolicyMerger)
+
+	var changed []RouteRef
+	for _, route := range diff.Changed {
+		changed = append(changed, route.RouteRef)
+	}
+	assert.DeepEqual(t, changed, []RouteRef{
+		{Gateway: "gw", Route: "api", Kind: GRPCRouteKind},
+		{Gateway: "gw", Route: "bare"},
+		{Gateway: "gw", Route: "internal"},
+	})
+	// AuthPolicies don't apply to TCPRoutes.
+	assert.DeepEqual(t, diff.Unchanged, []RouteRef{{Gateway: "gw", Route: "api", Kind: TCPRouteKind}, {Gateway: "gw", Route: "api"}})
+}
+
+func TestDiff_UnexportedFields(t *testing.T) {
+	value := 42
+	other := 420
+	enabled := true
+
+	assert.Equal(t, len(DiffPolicies(FakePolicy{value: &value}, FakePolicy{value: &value})), 0)
+	assert.DeepEqual(t, DiffPolicies(FakePolicy{value: &value}, FakePolicy{value: &other, enabled: &enabled}), []FieldDiff{
+		{Path: "enabled", Before: "<nil>", After: "true"},
+		{Path: "value", Before: "42", After: "420"},
+	})
+}
+
+func TestDiff_NamedEntries(t *testing.T) {
+	friends := &authorino.Identity{Name: "friends", Anonymous: &authorino.Identity_Anonymous{}}
+	sso := &authorino.Identity{Name: "sso", Oidc: &authorino.Identity_OidcConfig{Endpoint: "https://sso.my-company.io"}}
+
+	assert.Equal(t, len(DiffPolicies(
+		AuthPolicy{Identity: []*authorino.Identity{friends, sso}},
+		AuthPolicy{Identity: []*authorino.Identity{sso, friends}},
+	)), 0)
+	assert.DeepEqual(t, DiffPolicies(
+		AuthPolicy{Identity: []*authorino.Identity{friends}},
+		AuthPolicy{Identity: []*authorino.Identity{sso}},
+	), []FieldDiff{
+		{Path: "identity[friends]", Before: `{"name":"friends","credentials":{"keySelector":""},"anonymous":{}}`, After: "<nil>"},
+		{Path: "identity[sso]", Before: "<nil>", After: `{"name":"sso","credentials":{"keySelector":""},"oidc":{"endpoint":"https://sso.my-company.io"}}`},
+	})
+}
@@ -0,0 +1,159 @@
+package gw_policies_playground
+
+// Participation is how the defaults or the overrides of a PolicySpec take part in the effective policy of an object.
+type Participation string
+
+const (
+	ParticipationDefault  Participation = "Default"
+	ParticipationOverride Participation = "Override"
+
+	// ParticipationShadowed is for policies that have no effect, as higher precedence policies set all of their fields.
+	ParticipationShadowed Participation = "Shadowed"
+
+	// ParticipationSkipped is for policies whose type doesn't support the kind of the routes.
+	ParticipationSkipped Participation = "Skipped"
+
+	// ParticipationNotInherited is for Direct policies attached to an ancestor of the object.
+	ParticipationNotInherited Participation = "NotInherited"
+)
+
+// AffectingPolicy is either the defaults or the overrides of a PolicySpec influencing an object. A PolicySpec with
+// both is listed twice.
+type AffectingPolicy struct {
+	Name string `json:"name"`
+
+	// TargetKind is the level the policy is attached at, i.e. GatewayClass, Gateway or the RouteKind of a route, and
+	// TargetName the name of the object there.
+	TargetKind string `json:"targetKind"`
+	TargetName string `json:"targetName"`
+
+	// Inherited is set when the policy is attached to an ancestor of the object, rather than to the object itself.
+	Inherited bool `json:"inherited,omitempty"`
+
+	Class PolicyClass `json:"class"`
+
+	Participation Participation `json:"participation"`
+
+	// Overridden are the paths of the fields a higher precedence policy sets differently, when not Shadowed.
+	Overridden []string `json:"overridden,omitempty"`
+}
+
+// AffectingPolicies lists the policies attached to the route or inherited, from the highest precedence to the lowest.
+// They are all Skipped if the policy type doesn't support the kind of the route.
+func (r *routeBase[T]) AffectingPolicies(merger func(T, T) T) []AffectingPolicy {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	return affectingPolicies(r.node, r.contributions(), []routeNode[T]{r.node}, merger)
+}
+
+// AffectingPolicies lists the policies attached to the gateway and its class, from the highest precedence to the
+// lowest. A policy is only Shadowed if it has no effect on any of the routes of the gateway it applies to, and only
+// Skipped if it applies to none. Overridden lists the fields overridden on any of them.
+func (gw *Gateway[T]) AffectingPolicies(merger func(T, T) T) []AffectingPolicy {
+	gw.parent.lock.RLock()
+	defer gw.parent.lock.RUnlock()
+
+	return affectingPolicies(gw, gw.contributions(), routesBeneath[T](gw), merger)
+}
+
+// affectingPolicies expects the caller to hold the tree's lock. The effect of the contributions is evaluated on each
+// of the routes.
+func affectingPolicies[T Policy](object interface{}, contributions []policyContribution[T], routes []routeNode[T], merger func(T, T) T) []AffectingPolicy {
+	type key struct {
+		target   interface{}
+		index    int
+		override bool
+	}
+	type effect struct {
+		applied    bool
+		noEffect   bool
+		overridden []string
+	}
+	effects := make(map[key]*effect)
+	for _, route := range routes {
+		for _, contribution := range route.contributions() {
+			k := key{contribution.target, contribution.index, contribution.override}
+			if _, exists := effects[k]; !exists {
+				effects[k] = &effect{noEffect: true}
+			}
+		}
+		applicable := applicableContributions(route)
+		effective := foldContributions(applicable, merger)
+		for i, contribution := range applicable {
+			e := effects[key{contribution.target, contribution.index, contribution.override}]
+			noEffect, overridden := contributionEffect(applicable, i, effective, merger)
+			e.applied = true
+			e.noEffect = e.noEffect && noEffect
+			for _, path := range overridden {
+				e.overridden = appendUnique(e.overridden, path)
+			}
+		}
+	}
+
+	var empty T
+	var policies []AffectingPolicy
+	for _, contribution := range contributions {
+		if len(DiffPolicies(empty, contribution.policy)) == 0 {
+			continue
+		}
+		kind, name := targetKindAndName[T](contribution.target)
+		policy := AffectingPolicy{
+			Name:          contribution.name,
+			TargetKind:    kind,
+			TargetName:    name,
+			Inherited:     contribution.target != object,
+			Class:         InheritedPolicyClass,
+			Participation: ParticipationDefault,
+		}
+		if contribution.override {
+			policy.Participation = ParticipationOverride
+		}
+		if contribution.direct {
+			policy.Class = DirectPolicyClass
+		}
+		_, isRoute := object.(routeNode[T])
+		e, exists := effects[key{contribution.target, contribution.index, contribution.override}]
+		switch {
+		case contribution.direct && policy.Inherited:
+			policy.Participation = ParticipationNotInherited
+		case contribution.direct && !isRoute:
+			// The policy affects the gateway alone, not the routes its effect is evaluated on.
+		case !exists:
+		case !e.applied:
+			policy.Participation = ParticipationSkipped
+		case e.noEffect:
+			policy.Participation = ParticipationShadowed
+		default:
+			policy.Overridden = e.overridden
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// contributionEffect tells whether folding the contributions without the i-th one results in the same effective
+// policy, and which of the fields of the i-th one are overridden.
+func contributionEffect[T Policy](contributions []policyContribution[T], i int, effective T, merger func(T, T) T) (bool, []string) {
+	without := append(append([]policyContribution[T]{}, contributions[:i]...), contributions[i+1:]...)
+	noEffect := len(DiffPolicies(foldContributions(without, merger), effective)) == 0
+	var overridden []string
+	for _, diff := range DiffPolicies(effective, merger(contributions[i].policy, effective)) {
+		overridden = append(overridden, diff.Path)
+	}
+	return noEffect, overridden
+}
+
+func targetKindAndName[T Policy](target interface{}) (string, string) {
+	switch target := target.(type) {
+	case *GatewayClass[T]:
+		return "GatewayClass", target.name
+	case *Gateway[T]:
+		return "Gateway", target.name
+	case routeNode[T]:
+		return string(target.kind()), target.routeName()
+	case *Service[T]:
+		return "Service", target.name
+	}
+	return "", ""
+}
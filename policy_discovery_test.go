@@ -0,0 +1,54 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestAffectingPolicies_Route(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	enabled, disabled := true, false
+	one, two := 1, 2
+
+	gwc.AddPolicy(NewPolicySpec("class", FakePolicy{value: &one}, FakePolicy{enabled: &disabled}))
+	gw.AddPolicy(NewPolicySpec("gw", FakePolicy{enabled: &enabled}, FakePolicy{}))
+	route.AddPolicy(NewPolicySpec("route", FakePolicy{enabled: &enabled, value: &two}, FakePolicy{}))
+
+	assert.DeepEqual(t, route.AffectingPolicies(FakePolicyMerger), []AffectingPolicy{
+		{Name: "class", TargetKind: "GatewayClass", TargetName: "gwc1", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationOverride},
+		{Name: "route", TargetKind: "HTTPRoute", TargetName: "route", Class: InheritedPolicyClass, Participation: ParticipationDefault, Overridden: []string{"enabled"}},
+		{Name: "gw", TargetKind: "Gateway", TargetName: "gw", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationShadowed},
+		{Name: "class", TargetKind: "GatewayClass", TargetName: "gwc1", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationShadowed},
+	})
+}
+
+func TestAffectingPolicies_Gateway(t *testing.T) {
+	gwc := NewGatewayClass[FakePolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	shadowing := gw.CreateRoute("shadowing")
+	gw.CreateRoute("plain")
+
+	one, two := 1, 2
+
+	gw.AddPolicy(NewPolicySpec("gw", FakePolicy{value: &one}, FakePolicy{}))
+	gwc.AddPolicy(NewPolicySpec("class", FakePolicy{value: &two}, FakePolicy{}))
+	shadowing.AddPolicy(NewPolicySpec("route", FakePolicy{value: &two}, FakePolicy{}))
+
+	assert.DeepEqual(t, gw.AffectingPolicies(FakePolicyMerger), []AffectingPolicy{
+		{Name: "gw", TargetKind: "Gateway", TargetName: "gw", Class: InheritedPolicyClass, Participation: ParticipationDefault, Overridden: []string{"value"}},
+		{Name: "class", TargetKind: "GatewayClass", TargetName: "gwc1", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationShadowed},
+	})
+
+	route, exists := gw.Route("shadowing")
+	assert.Check(t, exists)
+	assert.Equal(t, route, shadowing)
+	_, exists = gw.Route("missing")
+	assert.Check(t, !exists)
+	found, exists := gwc.Gateway("gw")
+	assert.Check(t, exists)
+	assert.Equal(t, found, gw)
+}
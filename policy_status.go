@@ -0,0 +1,323 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+const (
+	ControllerName gatewayapi.GatewayController = "kuadrant.io/gw-policies-playground"
+
+	PolicyConditionAccepted   = "Accepted"
+	PolicyConditionOverridden = "Overridden"
+
+	PolicyReasonAccepted             = "Accepted"
+	PolicyReasonTargetNotFound       = "TargetNotFound"
+	PolicyReasonConflicted           = "Conflicted"
+	PolicyReasonOverridden           = "Overridden"
+	PolicyReasonPartiallyOverridden  = "PartiallyOverridden"
+	PolicyReasonEnforced             = "Enforced"
+	PolicyReasonUnsupportedRouteKind = "UnsupportedRouteKind"
+)
+
+// PolicyStatus follows the shape of the Gateway API policy status: one entry per ancestor, i.e. per Gateway, the
+// policy affects.
+type PolicyStatus struct {
+	Ancestors []PolicyAncestorStatus `json:"ancestors,omitempty"`
+}
+
+type PolicyAncestorStatus struct {
+	// AncestorRef is the Gateway the conditions relate to, or the target itself when it doesn't exist.
+	AncestorRef gatewayapi.ParentReference `json:"ancestorRef"`
+
+	ControllerName gatewayapi.GatewayController `json:"controllerName"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+func (s PolicyStatus) DeepCopy() PolicyStatus {
+	var out PolicyStatus
+	for _, ancestor := range s.Ancestors {
+		copied := PolicyAncestorStatus{ControllerName: ancestor.ControllerName}
+		ancestor.AncestorRef.DeepCopyInto(&copied.AncestorRef)
+		for _, condition := range ancestor.Conditions {
+			copied.Conditions = append(copied.Conditions, *condition.DeepCopy())
+		}
+		out.Ancestors = append(out.Ancestors, copied)
+	}
+	return out
+}
+
+// policyPosition identifies a PolicySpec within a hierarchy.
+type policyPosition struct {
+	target interface{}
+	index  int
+}
+
+// policyEffect is how a PolicySpec fares in the effective policy of a route.
+type policyEffect struct {
+	// noEffect is set when the effective policy would be the same without this one.
+	noEffect bool
+
+	// overridden are the paths of the fields of the policy that a higher precedence policy sets differently.
+	overridden []string
+
+	// conflicts are the names of the policies attached to the same target that take precedence over this one.
+	conflicts []string
+
+	// skipped is set when the policy type doesn't support the kind of the route.
+	skipped bool
+
+	// notInherited is set when the policy is Direct and attached to an ancestor of the route.
+	notInherited bool
+}
+
+// policyEffects expects the caller to hold the tree's lock. Policies that are empty aren't part of the result.
+func policyEffects[T Policy](route routeNode[T], merger func(T, T) T) map[policyPosition]*policyEffect {
+	var empty T
+	effects := make(map[policyPosition]*policyEffect)
+	if !supportsRouteKind[T](route.kind()) {
+		for _, contribution := range route.contributions() {
+			if len(DiffPolicies(empty, contribution.policy)) > 0 {
+				effects[policyPosition{target: contribution.target, index: contribution.index}] = &policyEffect{skipped: true}
+			}
+		}
+		return effects
+	}
+
+	for _, contribution := range route.contributions() {
+		if contribution.direct && contribution.target != route && len(DiffPolicies(empty, contribution.policy)) > 0 {
+			effects[policyPosition{target: contribution.target, index: contribution.index}] = &policyEffect{notInherited: true}
+		}
+	}
+
+	contributions := applicableContributions(route)
+	effective := foldContributions(contributions, merger)
+	for i, contribution := range contributions {
+		if len(DiffPolicies(empty, contribution.policy)) == 0 {
+			continue
+		}
+		position := policyPosition{target: contribution.target, index: contribution.index}
+		effect, exists := effects[position]
+		if !exists {
+			effect = &policyEffect{noEffect: true}
+			effects[position] = effect
+		}
+
+		noEffect, overridden := contributionEffect(contributions, i, effective, merger)
+		effect.noEffect = effect.noEffect && noEffect
+		for _, path := range overridden {
+			effect.overridden = appendUnique(effect.overridden, path)
+		}
+		for _, sibling := range contributions[:i] {
+			if sibling.target != contribution.target || sibling.index == contribution.index {
+				continue
+			}
+			shadowed := merger(sibling.policy, contribution.policy)
+			if len(DiffPolicies(shadowed, merger(contribution.policy, shadowed))) > 0 {
+				effect.conflicts = appendUnique(effect.conflicts, sibling.name)
+			}
+		}
+	}
+	return effects
+}
+
+// PolicyStatuses computes the status of every policy CR of the topology, keyed by the policy's namespaced name.
+func (t *Topology[T]) PolicyStatuses(merger func(T, T) T) map[types.NamespacedName]PolicyStatus {
+	statuses := make(map[types.NamespacedName]PolicyStatus)
+	for _, gwc := range t.Classes {
+		gwc.lock.RLock()
+		defer gwc.lock.RUnlock()
+	}
+
+	for _, policy := range t.Unattached {
+		target := policy.Spec.TargetRef
+		namespace := target.Namespace
+		if namespace == "" {
+			namespace = policy.Namespace
+		}
+		statuses[types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}] = PolicyStatus{
+			Ancestors: []PolicyAncestorStatus{{
+				AncestorRef:    parentRef(target.Group, target.Kind, types.NamespacedName{Namespace: namespace, Name: target.Name}),
+				ControllerName: ControllerName,
+				Conditions: []metav1.Condition{{
+					Type:               PolicyConditionAccepted,
+					Status:             metav1.ConditionFalse,
+					Reason:             PolicyReasonTargetNotFound,
+					Message:            fmt.Sprintf("%s %s/%s not found", target.Kind, namespace, target.Name),
+					ObservedGeneration: policy.Generation,
+					LastTransitionTime: metav1.Now(),
+				}},
+			}},
+		}
+	}
+
+	gatewayKeys := make(map[*Gateway[T]]types.NamespacedName, len(t.Gateways))
+	for key, gw := range t.Gateways {
+		gatewayKeys[gw] = key
+	}
+	routeEffects := make(map[routeNode[T]]map[policyPosition]*policyEffect)
+	effectsOf := func(route routeNode[T]) map[policyPosition]*policyEffect {
+		if _, exists := routeEffects[route]; !exists {
+			routeEffects[route] = policyEffects(route, merger)
+		}
+		return routeEffects[route]
+	}
+
+	for policy, positions := range t.attachments {
+		affected := make(map[types.NamespacedName][]routeEffect)
+		for _, position := range positions {
+			for _, route := range routesBeneath[T](position.target) {
+				key := gatewayKeys[route.gateway()]
+				affected[key] = append(affected[key], routeEffect{
+					kind:   route.kind(),
+					route:  route.routeName(),
+					effect: effectsOf(route)[position],
+				})
+			}
+		}
+		if len(affected) == 0 {
+			for _, position := range positions {
+				if gw, ok := position.target.(*Gateway[T]); ok {
+					affected[gatewayKeys[gw]] = nil
+				}
+			}
+		}
+
+		var status PolicyStatus
+		for _, gateway := range sortedNamespacedNames(affected) {
+			status.Ancestors = append(status.Ancestors, PolicyAncestorStatus{
+				AncestorRef:    parentRef(gatewayapi.GroupName, "Gateway", gateway),
+				ControllerName: ControllerName,
+				Conditions:     policyConditions(affected[gateway], policy.Generation),
+			})
+		}
+		statuses[types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}] = status
+	}
+
+	return statuses
+}
+
+type routeEffect struct {
+	kind   RouteKind
+	route  string
+	effect *policyEffect
+}
+
+func policyConditions(routes []routeEffect, generation int64) []metav1.Condition {
+	accepted := metav1.Condition{
+		Type:               PolicyConditionAccepted,
+		Status:             metav1.ConditionTrue,
+		Reason:             PolicyReasonAccepted,
+		Message:            "Policy has been accepted",
+		ObservedGeneration: generation,
+		LastTransitionTime: metav1.Now(),
+	}
+	if len(routes) == 0 {
+		return []metav1.Condition{accepted}
+	}
+	overridden := metav1.Condition{
+		Type:               PolicyConditionOverridden,
+		Status:             metav1.ConditionFalse,
+		Reason:             PolicyReasonEnforced,
+		Message:            "Policy is enforced",
+		ObservedGeneration: generation,
+		LastTransitionTime: metav1.Now(),
+	}
+
+	noEffect, applied, direct := true, false, false
+	var conflicts, partial, skipped []string
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].kind != routes[j].kind {
+			return routes[i].kind < routes[j].kind
+		}
+		return routes[i].route < routes[j].route
+	})
+	for _, route := range routes {
+		if route.effect != nil && route.effect.skipped {
+			skipped = append(skipped, fmt.Sprintf("%s %s", route.kind, route.route))
+			continue
+		}
+		if route.effect != nil && route.effect.notInherited {
+			direct = true
+			continue
+		}
+		applied = true
+		if route.effect == nil || !route.effect.noEffect {
+			noEffect = false
+		}
+		if route.effect == nil {
+			continue
+		}
+		for _, name := range route.effect.conflicts {
+			conflicts = appendUnique(conflicts, name)
+		}
+		if len(route.effect.overridden) > 0 {
+			partial = append(partial, fmt.Sprintf("%s (%s)", route.route, strings.Join(route.effect.overridden, ", ")))
+		}
+	}
+
+	if direct {
+		// Direct policies attached to a route apply to it, so this one is attached to a gateway or a class.
+		accepted.Message = "Policy has been accepted, it's Direct and only affects its target, not the routes beneath it"
+		return []metav1.Condition{accepted}
+	}
+	if !applied {
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = PolicyReasonUnsupportedRouteKind
+		accepted.Message = fmt.Sprintf("Policy doesn't apply to the kind of any of its routes: %s", strings.Join(skipped, ", "))
+		return []metav1.Condition{accepted}
+	}
+	if len(skipped) > 0 {
+		accepted.Message = fmt.Sprintf("Policy has been accepted, but is skipped on routes of unsupported kinds: %s", strings.Join(skipped, ", "))
+	}
+
+	switch {
+	case noEffect && len(conflicts) > 0:
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = PolicyReasonConflicted
+		accepted.Message = fmt.Sprintf("Policy is shadowed by policies on the same target: %s", strings.Join(conflicts, ", "))
+		fallthrough
+	case noEffect:
+		overridden.Status = metav1.ConditionTrue
+		overridden.Reason = PolicyReasonOverridden
+		overridden.Message = "Policy has no effect on any route, as higher precedence policies override it"
+	case len(partial) > 0:
+		overridden.Status = metav1.ConditionTrue
+		overridden.Reason = PolicyReasonPartiallyOverridden
+		overridden.Message = fmt.Sprintf("Fields are overridden by higher precedence policies on %s", strings.Join(partial, "; "))
+	}
+	return []metav1.Condition{accepted, overridden}
+}
+
+func parentRef(group, kind string, key types.NamespacedName) gatewayapi.ParentReference {
+	g := gatewayapi.Group(group)
+	k := gatewayapi.Kind(kind)
+	ref := gatewayapi.ParentReference{Group: &g, Kind: &k, Name: gatewayapi.ObjectName(key.Name)}
+	if key.Namespace != "" {
+		namespace := gatewayapi.Namespace(key.Namespace)
+		ref.Namespace = &namespace
+	}
+	return ref
+}
+
+func sortedNamespacedNames[V any](m map[types.NamespacedName]V) []types.NamespacedName {
+	keys := make([]types.NamespacedName, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,155 @@
+package gw_policies_playground
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	authorino "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func testStatusTopology(policies ...*PolicyCR[AuthPolicy]) map[types.NamespacedName]PolicyStatus {
+	gw := types.NamespacedName{Namespace: "gateway-system", Name: "gw"}
+	var items []PolicyCR[AuthPolicy]
+	for _, policy := range policies {
+		items = append(items, *policy)
+	}
+	topology := NewTopology(
+		[]gatewayapi.Gateway{*testGateway(gw.Namespace, gw.Name)},
+		[]gatewayapi.HTTPRoute{*testHTTPRoute("toystore", "toystore", gw), *testHTTPRoute("toystore", "other", gw)},
+		nil,
+		nil,
+		nil,
+		items,
+	)
+	return topology.PolicyStatuses(AuthPolicyMerger)
+}
+
+func condition(t *testing.T, status PolicyStatus, conditionType string) *metav1.Condition {
+	assert.Equal(t, len(status.Ancestors), 1)
+	assert.Equal(t, string(status.Ancestors[0].ControllerName), string(ControllerName))
+	found := meta.FindStatusCondition(status.Ancestors[0].Conditions, conditionType)
+	assert.Check(t, found != nil, "no %s condition", conditionType)
+	return found
+}
+
+func TestPolicyStatus_TargetNotFound(t *testing.T) {
+	statuses := testStatusTopology(newTestAuthPolicyCR("toystore", "lost", "HTTPRoute", "missing", &testAuthPolicySpec1, nil, 0))
+
+	status := statuses[types.NamespacedName{Namespace: "toystore", Name: "lost"}]
+	assert.Equal(t, string(status.Ancestors[0].AncestorRef.Name), "missing")
+	assert.Equal(t, string(*status.Ancestors[0].AncestorRef.Kind), "HTTPRoute")
+	accepted := condition(t, status, PolicyConditionAccepted)
+	assert.Equal(t, accepted.Status, metav1.ConditionFalse)
+	assert.Equal(t, accepted.Reason, PolicyReasonTargetNotFound)
+	assert.Equal(t, accepted.Message, "HTTPRoute toystore/missing not found")
+}
+
+func TestPolicyStatus_Enforced(t *testing.T) {
+	statuses := testStatusTopology(newTestAuthPolicyCR("toystore", "route", "HTTPRoute", "toystore", &testAuthPolicySpec1, nil, 0))
+
+	status := statuses[types.NamespacedName{Namespace: "toystore", Name: "route"}]
+	assert.Equal(t, string(status.Ancestors[0].AncestorRef.Name), "gw")
+	assert.Equal(t, string(*status.Ancestors[0].AncestorRef.Namespace), "gateway-system")
+	assert.Equal(t, condition(t, status, PolicyConditionAccepted).Reason, PolicyReasonAccepted)
+	overridden := condition(t, status, PolicyConditionOverridden)
+	assert.Equal(t, overridden.Status, metav1.ConditionFalse)
+	assert.Equal(t, overridden.Reason, PolicyReasonEnforced)
+}
+
+func TestPolicyStatus_Overridden(t *testing.T) {
+	gwDefault := AuthPolicy{
+		Identity: []*authorino.Identity{{Name: "friends", Anonymous: &authorino.Identity_Anonymous{}}},
+	}
+	statuses := testStatusTopology(
+		newTestAuthPolicyCR("gateway-system", "gw", "Gateway", "gw", &gwDefault, nil, 0),
+		newTestAuthPolicyCR("toystore", "toystore", "HTTPRoute", "toystore", &testAuthPolicySpec2, nil, time.Hour),
+		newTestAuthPolicyCR("toystore", "other", "HTTPRoute", "other", &testAuthPolicySpec2, nil, time.Hour),
+	)
+
+	status := statuses[types.NamespacedName{Namespace: "gateway-system", Name: "gw"}]
+	assert.Equal(t, condition(t, status, PolicyConditionAccepted).Status, metav1.ConditionTrue)
+	overridden := condition(t, status, PolicyConditionOverridden)
+	assert.Equal(t, overridden.Status, metav1.ConditionTrue)
+	assert.Equal(t, overridden.Reason, PolicyReasonOverridden)
+}
+
+func TestPolicyStatus_PartiallyOverridden(t *testing.T) {
+	statuses := testStatusTopology(
+		newTestAuthPolicyCR("gateway-system", "gw", "Gateway", "gw", &testAuthPolicySpec1, nil, 0),
+		newTestAuthPolicyCR("toystore", "toystore", "HTTPRoute", "toystore", nil, &testAuthPolicySpec2, time.Hour),
+	)
+
+	status := statuses[types.NamespacedName{Namespace: "gateway-system", Name: "gw"}]
+	overridden := condition(t, status, PolicyConditionOverridden)
+	assert.Equal(t, overridden.Status, metav1.ConditionTrue)
+	assert.Equal(t, overridden.Reason, PolicyReasonPartiallyOverridden)
+	assert.Check(t, strings.Contains(overridden.Message, "toystore/toystore ("), overridden.Message)
+	assert.Check(t, strings.Contains(overridden.Message, "identity[friends].anonymous"), overridden.Message)
+	assert.Check(t, strings.Contains(overridden.Message, "patterns[api-version]"), overridden.Message)
+	assert.Check(t, !strings.Contains(overridden.Message, "toystore/other"), overridden.Message)
+
+	status = statuses[types.NamespacedName{Namespace: "toystore", Name: "toystore"}]
+	assert.Equal(t, condition(t, status, PolicyConditionOverridden).Reason, PolicyReasonEnforced)
+}
+
+func TestPolicyStatus_Conflicted(t *testing.T) {
+	statuses := testStatusTopology(
+		newTestAuthPolicyCR("toystore", "first", "HTTPRoute", "toystore", &testAuthPolicySpec2, nil, 0),
+		newTestAuthPolicyCR("toystore", "second", "HTTPRoute", "toystore", &AuthPolicy{
+			Identity: []*authorino.Identity{{Name: "friends", Anonymous: &authorino.Identity_Anonymous{}}},
+		}, nil, time.Hour),
+	)
+
+	status := statuses[types.NamespacedName{Namespace: "toystore", Name: "second"}]
+	accepted := condition(t, status, PolicyConditionAccepted)
+	assert.Equal(t, accepted.Status, metav1.ConditionFalse)
+	assert.Equal(t, accepted.Reason, PolicyReasonConflicted)
+	assert.Equal(t, accepted.Message, "Policy is shadowed by policies on the same target: first")
+	assert.Equal(t, condition(t, status, PolicyConditionOverridden).Reason, PolicyReasonOverridden)
+
+	status = statuses[types.NamespacedName{Namespace: "toystore", Name: "first"}]
+	assert.Equal(t, condition(t, status, PolicyConditionAccepted).Reason, PolicyReasonAccepted)
+}
+
+func TestPolicyStatus_UnsupportedRouteKind(t *testing.T) {
+	gw := types.NamespacedName{Namespace: "gateway-system", Name: "gw"}
+	gwNamespace := gatewayapi.Namespace(gw.Namespace)
+	tcpRoute := gatewayapi.TCPRoute{ObjectMeta: metav1.ObjectMeta{Namespace: "toystore", Name: "db"}}
+	tcpRoute.Spec.ParentRefs = []gatewayapi.ParentReference{{Namespace: &gwNamespace, Name: gatewayapi.ObjectName(gw.Name)}}
+	tlsRoute := gatewayapi.TLSRoute{ObjectMeta: metav1.ObjectMeta{Namespace: "toystore", Name: "tls"}}
+	tlsRoute.Spec.ParentRefs = tcpRoute.Spec.ParentRefs
+
+	topology := NewTopology(
+		[]gatewayapi.Gateway{*testGateway(gw.Namespace, gw.Name)},
+		[]gatewayapi.HTTPRoute{*testHTTPRoute("toystore", "toystore", gw)},
+		nil,
+		[]gatewayapi.TCPRoute{tcpRoute},
+		[]gatewayapi.TLSRoute{tlsRoute},
+		[]PolicyCR[AuthPolicy]{
+			*newTestAuthPolicyCR("gateway-system", "gw", "Gateway", "gw", &testAuthPolicySpec1, nil, 0),
+			*newTestAuthPolicyCR("toystore", "db", "TCPRoute", "db", &testAuthPolicySpec2, nil, 0),
+		},
+	)
+	assert.Equal(t, len(topology.TCPRoutes[types.NamespacedName{Namespace: "toystore", Name: "db"}]), 1)
+	assert.DeepEqual(t, topology.TLSRoutes[types.NamespacedName{Namespace: "toystore", Name: "tls"}][0].MergedPolicies(AuthPolicyMerger), AuthPolicy{})
+	statuses := topology.PolicyStatuses(AuthPolicyMerger)
+
+	status := statuses[types.NamespacedName{Namespace: "gateway-system", Name: "gw"}]
+	accepted := condition(t, status, PolicyConditionAccepted)
+	assert.Equal(t, accepted.Status, metav1.ConditionTrue)
+	assert.Equal(t, accepted.Message, "Policy has been accepted, but is skipped on routes of unsupported kinds: TCPRoute toystore/db, TLSRoute toystore/tls")
+	assert.Equal(t, condition(t, status, PolicyConditionOverridden).Reason, PolicyReasonEnforced)
+
+	status = statuses[types.NamespacedName{Namespace: "toystore", Name: "db"}]
+	accepted = condition(t, status, PolicyConditionAccepted)
+	assert.Equal(t, accepted.Status, metav1.ConditionFalse)
+	assert.Equal(t, accepted.Reason, PolicyReasonUnsupportedRouteKind)
+	assert.Equal(t, len(status.Ancestors[0].Conditions), 1)
+}
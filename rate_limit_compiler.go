@@ -0,0 +1,114 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// CompiledLimit is a rate of a limit of the effective RateLimitPolicies of a class, as Limitador would be configured
+// with: the routes whose effective policies hold the same limit, within the same counter namespace, share its
+// counters.
+type CompiledLimit struct {
+	// Namespace is where the counters of the limit live, after its scope, e.g. `gateway:gw` for the limits scoped to
+	// the gateway `gw`.
+	Namespace string
+
+	Name       string
+	Conditions []WhenCondition
+	Variables  []ContextSelector
+	MaxValue   int
+	Seconds    int
+
+	// Routes are the routes the limit applies to, sharing its counters.
+	Routes []RouteRef
+}
+
+// SharesCounters tells whether requests to more than one route count towards the limit.
+func (l CompiledLimit) SharesCounters() bool {
+	return len(l.Routes) > 1
+}
+
+// CompileLimits merges the RateLimitPolicies of every route of the class, of all kinds, and compiles their limits.
+// Limits of the same name and definition share their counters when their scope does, otherwise they're compiled once
+// per route. The result is sorted by namespace, name and window.
+func CompileLimits(gwc *GatewayClass[RateLimitPolicy], merger func(RateLimitPolicy, RateLimitPolicy) RateLimitPolicy) []CompiledLimit {
+	gwc.lock.RLock()
+	defer gwc.lock.RUnlock()
+
+	compiled := make(map[string]*CompiledLimit)
+	for _, route := range routesBeneath[RateLimitPolicy](gwc) {
+		ref := routeRefOf(route)
+		policy := foldContributions(applicableContributions[RateLimitPolicy](route), merger)
+		for _, l := range compileRouteLimits(gwc.name, ref, policy) {
+			l := l
+			key := l.key()
+			if existing, exists := compiled[key]; exists {
+				existing.Routes = append(existing.Routes, ref)
+				continue
+			}
+			l.Routes = []RouteRef{ref}
+			compiled[key] = &l
+		}
+	}
+
+	limits := make([]CompiledLimit, 0, len(compiled))
+	for _, l := range compiled {
+		sort.Slice(l.Routes, func(i, j int) bool { return l.Routes[i].String() < l.Routes[j].String() })
+		limits = append(limits, *l)
+	}
+	sortLimits(limits)
+	return limits
+}
+
+func sortLimits(limits []CompiledLimit) {
+	sort.Slice(limits, func(i, j int) bool {
+		if limits[i].Namespace != limits[j].Namespace {
+			return limits[i].Namespace < limits[j].Namespace
+		}
+		if limits[i].Name != limits[j].Name {
+			return limits[i].Name < limits[j].Name
+		}
+		if limits[i].Seconds != limits[j].Seconds {
+			return limits[i].Seconds < limits[j].Seconds
+		}
+		return limits[i].key() < limits[j].key()
+	})
+}
+
+// compileRouteLimits leaves the routes of the limits empty.
+func compileRouteLimits(class string, route RouteRef, policy RateLimitPolicy) []CompiledLimit {
+	var limits []CompiledLimit
+	for name, limit := range policy.Limits {
+		for _, rate := range limit.Rates {
+			limits = append(limits, CompiledLimit{
+				Namespace:  counterNamespace(class, route, limit.Scope),
+				Name:       name,
+				Conditions: limit.When,
+				Variables:  limit.Counters,
+				MaxValue:   rate.Limit,
+				Seconds:    rate.Seconds(),
+			})
+		}
+	}
+	return limits
+}
+
+func counterNamespace(class string, route RouteRef, scope CounterScope) string {
+	switch scope {
+	case "", RouteCounterScope:
+		return fmt.Sprintf("route:%s:%s/%s", route.routeKind(), route.Gateway, route.Route)
+	case GatewayCounterScope:
+		return fmt.Sprintf("gateway:%s", route.Gateway)
+	case ClassCounterScope:
+		return fmt.Sprintf("class:%s", class)
+	}
+	return fmt.Sprintf("key:%s", scope)
+}
+
+// key identifies the counters of the limit, i.e. everything but the routes.
+func (l CompiledLimit) key() string {
+	l.Routes = nil
+	key, _ := json.Marshal(l)
+	return string(key)
+}
@@ -0,0 +1,60 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCompileLimits_CounterScopes(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw1 := gwc.CreateGateway("gw1")
+	gw2 := gwc.CreateGateway("gw2")
+	api := gw1.CreateRoute("api")
+	gw1.CreateRoute("web")
+	gw1.CreateTcpRoute("db")
+	gw2.CreateRoute("api")
+
+	subnet := testRateLimitPolicySpec1.Limits["subnet"]
+	subnet.Scope = GatewayCounterScope
+	perUser := testRateLimitPolicySpec2.Limits["per-user"]
+	global := Limit{Rates: []Rate{{Limit: 1000, Duration: 1, Unit: MinuteTimeUnit}}, Scope: "global"}
+	gwc.AddPolicy(PolicySpec[RateLimitPolicy]{name: "global", defaults: RateLimitPolicy{Limits: map[string]Limit{"global": global}}})
+	gw1.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw1", defaults: RateLimitPolicy{Limits: map[string]Limit{"subnet": subnet, "per-user": perUser}}})
+	gw2.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw2", defaults: RateLimitPolicy{Limits: map[string]Limit{"subnet": subnet}}})
+
+	stricter := subnet
+	stricter.Rates = []Rate{{Limit: 5, Duration: 1, Unit: MinuteTimeUnit}}
+	api.AddPolicy(PolicySpec[RateLimitPolicy]{name: "api", defaults: RateLimitPolicy{Limits: map[string]Limit{"subnet": stricter}}})
+
+	limits := CompileLimits(&gwc, RateLimitPolicyMerger)
+
+	type compiled struct {
+		Namespace, Name string
+		MaxValue        int
+		Routes          []string
+	}
+	var actual []compiled
+	for _, l := range limits {
+		var routes []string
+		for _, route := range l.Routes {
+			routes = append(routes, route.String())
+		}
+		actual = append(actual, compiled{l.Namespace, l.Name, l.MaxValue, routes})
+	}
+	assert.DeepEqual(t, actual, []compiled{
+		{"gateway:gw1", "subnet", 10, []string{"TCPRoute gw1/db", "gw1/web"}},
+		{"gateway:gw1", "subnet", 5, []string{"gw1/api"}},
+		{"gateway:gw2", "subnet", 10, []string{"gw2/api"}},
+		{"key:global", "global", 1000, []string{"TCPRoute gw1/db", "gw1/api", "gw1/web", "gw2/api"}},
+		{"route:HTTPRoute:gw1/api", "per-user", 100, []string{"gw1/api"}},
+		{"route:HTTPRoute:gw1/web", "per-user", 100, []string{"gw1/web"}},
+		{"route:TCPRoute:gw1/db", "per-user", 100, []string{"TCPRoute gw1/db"}},
+	})
+
+	assert.Equal(t, limits[0].Seconds, 60)
+	assert.Equal(t, limits[4].Seconds, 3600)
+	assert.DeepEqual(t, limits[4].Variables, []ContextSelector{"auth.identity.username"})
+	assert.Check(t, limits[0].SharesCounters())
+	assert.Check(t, !limits[4].SharesCounters())
+}
@@ -0,0 +1,111 @@
+package gw_policies_playground
+
+type RateLimitPolicy struct {
+	// Limits holds the struct of limits indexed by a unique name.
+	Limits map[string]Limit `json:"limits,omitempty"`
+}
+
+type Limit struct {
+	// When holds the list of conditions for the policy to be enforced.
+	// Called also "soft" conditions as route selectors must also match.
+	When []WhenCondition `json:"when,omitempty"`
+
+	// Counters defines additional rate limit counters based on context qualifiers and well known selectors.
+	Counters []ContextSelector `json:"counters,omitempty"`
+
+	// Rates holds the list of limit rates.
+	Rates []Rate `json:"rates,omitempty"`
+
+	// Scope tells which routes share the counters of the limit: each route has its own (`route`, the default), or the
+	// routes of a same gateway (`gateway`) or of the whole class (`class`) share them. Any other value is a custom key,
+	// whose counters are shared by all the routes of the class with a limit of the same name and key.
+	Scope CounterScope `json:"scope,omitempty"`
+}
+
+type CounterScope string
+
+const (
+	RouteCounterScope   CounterScope = "route"
+	GatewayCounterScope CounterScope = "gateway"
+	ClassCounterScope   CounterScope = "class"
+)
+
+// ContextSelector defines one item from the well known attributes, e.g. `auth.identity.username`.
+type ContextSelector string
+
+type WhenConditionOperator string
+
+const (
+	EqualOperator      WhenConditionOperator = "eq"
+	NotEqualOperator   WhenConditionOperator = "neq"
+	StartsWithOperator WhenConditionOperator = "startswith"
+	EndsWithOperator   WhenConditionOperator = "endswith"
+	IncludeOperator    WhenConditionOperator = "incl"
+	ExcludeOperator    WhenConditionOperator = "excl"
+	MatchesOperator    WhenConditionOperator = "matches"
+)
+
+// WhenCondition defines semantics for matching an HTTP request based on conditions. A request without the attribute
+// only matches the `neq` and `excl` conditions, as an empty value.
+type WhenCondition struct {
+	// Selector defines one item from the well known selectors.
+	Selector ContextSelector `json:"selector"`
+
+	// The binary operator to be applied to the content fetched from the selector.
+	Operator WhenConditionOperator `json:"operator"`
+
+	// The value of reference for the comparison.
+	Value string `json:"value"`
+}
+
+type TimeUnit string
+
+const (
+	SecondTimeUnit TimeUnit = "second"
+	MinuteTimeUnit TimeUnit = "minute"
+	HourTimeUnit   TimeUnit = "hour"
+	DayTimeUnit    TimeUnit = "day"
+)
+
+// Rate defines the actual rate limit that will be used when there is a match.
+type Rate struct {
+	// Limit defines the max value allowed for a given period of time.
+	Limit int `json:"limit"`
+
+	// Duration defines the time period for which the Limit specified above applies.
+	Duration int `json:"duration"`
+
+	// Unit defines the time unit, i.e. second, minute, hour or day.
+	Unit TimeUnit `json:"unit"`
+}
+
+// Seconds is the length of the window of the rate.
+func (r Rate) Seconds() int {
+	switch r.Unit {
+	case MinuteTimeUnit:
+		return r.Duration * 60
+	case HourTimeUnit:
+		return r.Duration * 60 * 60
+	case DayTimeUnit:
+		return r.Duration * 24 * 60 * 60
+	}
+	return r.Duration
+}
+
+func RateLimitPolicyMerger(p1, p2 RateLimitPolicy) RateLimitPolicy {
+	result := RateLimitPolicy{
+		Limits: make(map[string]Limit),
+	}
+
+	for name, limit := range p1.Limits {
+		result.Limits[name] = limit
+	}
+	for name, limit := range p2.Limits {
+		if _, exists := result.Limits[name]; exists {
+			continue
+		}
+		result.Limits[name] = limit
+	}
+
+	return result
+}
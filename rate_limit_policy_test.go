@@ -0,0 +1,77 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+var (
+	testRateLimitPolicySpec1 = RateLimitPolicy{
+		Limits: map[string]Limit{
+			"subnet": {
+				When: []WhenCondition{
+					{Selector: "source.address", Operator: MatchesOperator, Value: `^10\.`},
+				},
+				Rates: []Rate{{Limit: 10, Duration: 60, Unit: SecondTimeUnit}},
+			},
+		},
+	}
+
+	testRateLimitPolicySpec2 = RateLimitPolicy{
+		Limits: map[string]Limit{
+			"subnet": {
+				When: []WhenCondition{
+					{Selector: "source.address", Operator: MatchesOperator, Value: `^10\.`},
+					{Selector: "auth.identity.group", Operator: NotEqualOperator, Value: "admin"},
+				},
+				Rates: []Rate{{Limit: 10, Duration: 60, Unit: SecondTimeUnit}},
+			},
+			"per-user": {
+				Counters: []ContextSelector{"auth.identity.username"},
+				Rates:    []Rate{{Limit: 100, Duration: 1, Unit: HourTimeUnit}},
+			},
+		},
+	}
+)
+
+func TestRateLimitMerge_GatewayOverride_RouteDefault(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	gw.AddPolicy(PolicySpec[RateLimitPolicy]{
+		name:      "rate-limit-policy",
+		overrides: testRateLimitPolicySpec1,
+	})
+	route.AddPolicy(PolicySpec[RateLimitPolicy]{
+		name:     "rate-limit-policy",
+		defaults: testRateLimitPolicySpec2,
+	})
+
+	result := route.MergedPolicies(RateLimitPolicyMerger)
+
+	assert.Equal(t, len(result.Limits), 2)
+	assert.Equal(t, len(result.Limits["subnet"].When), 1)
+	assert.Equal(t, result.Limits["per-user"].Rates[0].Limit, 100)
+}
+
+func TestRateLimitMerge_GatewayDefault_RouteDefault(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	gw.AddPolicy(PolicySpec[RateLimitPolicy]{
+		name:     "rate-limit-policy",
+		defaults: testRateLimitPolicySpec1,
+	})
+	route.AddPolicy(PolicySpec[RateLimitPolicy]{
+		name:     "rate-limit-policy",
+		defaults: testRateLimitPolicySpec2,
+	})
+
+	result := route.MergedPolicies(RateLimitPolicyMerger)
+
+	assert.Equal(t, len(result.Limits), 2)
+	assert.Equal(t, len(result.Limits["subnet"].When), 2)
+}
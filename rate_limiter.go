@@ -0,0 +1,197 @@
+package gw_policies_playground
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clock is the time source of a Limiter.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// FakeClock only moves forward when told so.
+type FakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Request is what a Limiter knows of a request: the route it hits, and the values of the well known selectors, e.g.
+// `source.address` or `auth.identity.username`.
+type Request struct {
+	Route      RouteRef
+	Attributes map[ContextSelector]string
+}
+
+// Decision is the outcome of checking a request against the limits.
+type Decision struct {
+	Allowed bool
+
+	// LimitedBy is the first limit the request exceeds, and RetryAfter the time left until its window resets.
+	LimitedBy  *CompiledLimit
+	RetryAfter time.Duration
+}
+
+// Limiter enforces compiled limits in memory, with fixed window counters as Limitador does: a limit applies to a
+// request when all of its conditions match and all of its variables have a value, and each combination of the values
+// of its variables has its own counter, whose window starts with the first request it counts.
+type Limiter struct {
+	clock  Clock
+	limits []compiledLimit
+
+	lock     sync.Mutex
+	counters map[string]*window
+}
+
+type compiledLimit struct {
+	CompiledLimit
+	key      string
+	patterns map[int]*regexp.Regexp
+}
+
+type window struct {
+	expires time.Time
+	hits    int
+}
+
+// NewLimiter enforces the limits on the requests to their routes. Limits without routes apply to all requests. A nil
+// clock is the system one.
+func NewLimiter(limits []CompiledLimit, clock Clock) (*Limiter, error) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	limiter := &Limiter{clock: clock, counters: make(map[string]*window)}
+	for _, l := range limits {
+		compiled := compiledLimit{CompiledLimit: l, key: l.key(), patterns: make(map[int]*regexp.Regexp)}
+		for i, condition := range l.Conditions {
+			if condition.Operator != MatchesOperator {
+				continue
+			}
+			pattern, err := regexp.Compile(condition.Value)
+			if err != nil {
+				return nil, fmt.Errorf("limit %s: %w", l.Name, err)
+			}
+			compiled.patterns[i] = pattern
+		}
+		limiter.limits = append(limiter.limits, compiled)
+	}
+	return limiter, nil
+}
+
+// NewRouteLimiter enforces the limits of the effective policy of the route on all requests. Counters are still kept
+// apart by scope, though only the ones of the route are ever hit.
+func NewRouteLimiter(route *HttpRoute[RateLimitPolicy], merger func(RateLimitPolicy, RateLimitPolicy) RateLimitPolicy, clock Clock) (*Limiter, error) {
+	policy := route.MergedPolicies(merger)
+	limits := compileRouteLimits(route.parent.parent.name, routeRefOf[RateLimitPolicy](route), policy)
+	sortLimits(limits)
+	return NewLimiter(limits, clock)
+}
+
+// Check counts the request towards all the limits that apply to it, unless it exceeds any of them, in which case none
+// is counted and the request isn't allowed.
+func (l *Limiter) Check(request Request) Decision {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	var hit []*window
+	for i := range l.limits {
+		limit := &l.limits[i]
+		counter, applies := limit.counterKey(request)
+		if !applies {
+			continue
+		}
+		w, exists := l.counters[counter]
+		if !exists || !now.Before(w.expires) {
+			w = &window{expires: now.Add(time.Duration(limit.Seconds) * time.Second)}
+			l.counters[counter] = w
+		}
+		if w.hits >= limit.MaxValue {
+			return Decision{LimitedBy: &limit.CompiledLimit, RetryAfter: w.expires.Sub(now)}
+		}
+		hit = append(hit, w)
+	}
+	for _, w := range hit {
+		w.hits++
+	}
+	return Decision{Allowed: true}
+}
+
+// counterKey tells whether the limit applies to the request and, if so, which of its counters the request hits. A
+// condition on an attribute the request lacks only holds for the negative operators, the attribute being empty then:
+// an unauthenticated request is not in the `admin` group. A counter the request lacks doesn't apply.
+func (l *compiledLimit) counterKey(request Request) (string, bool) {
+	if len(l.Routes) > 0 && !containsRouteRef(l.Routes, request.Route) {
+		return "", false
+	}
+	for i, condition := range l.Conditions {
+		value, exists := request.Attributes[condition.Selector]
+		if !exists && condition.Operator != NotEqualOperator && condition.Operator != ExcludeOperator {
+			return "", false
+		}
+		if !l.matches(i, condition, value) {
+			return "", false
+		}
+	}
+	key := l.key
+	for _, variable := range l.Variables {
+		value, exists := request.Attributes[variable]
+		if !exists {
+			return "", false
+		}
+		key += fmt.Sprintf("\x00%s=%s", variable, value)
+	}
+	return key, true
+}
+
+func (l *compiledLimit) matches(i int, condition WhenCondition, value string) bool {
+	switch condition.Operator {
+	case EqualOperator:
+		return value == condition.Value
+	case NotEqualOperator:
+		return value != condition.Value
+	case StartsWithOperator:
+		return strings.HasPrefix(value, condition.Value)
+	case EndsWithOperator:
+		return strings.HasSuffix(value, condition.Value)
+	case IncludeOperator:
+		return strings.Contains(value, condition.Value)
+	case ExcludeOperator:
+		return !strings.Contains(value, condition.Value)
+	case MatchesOperator:
+		return l.patterns[i].MatchString(value)
+	}
+	return false
+}
+
+func containsRouteRef(routes []RouteRef, route RouteRef) bool {
+	for _, r := range routes {
+		if r.normalized() == route.normalized() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package gw_policies_playground
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestLimiter_Subnet(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	route := gwc.CreateGateway("gw").CreateRoute("route")
+	route.AddPolicy(PolicySpec[RateLimitPolicy]{name: "rate-limit-policy", defaults: testRateLimitPolicySpec2})
+
+	clock := NewFakeClock(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))
+	limiter, err := NewRouteLimiter(route, RateLimitPolicyMerger, clock)
+	assert.NilError(t, err)
+
+	request := func(address, group string) Request {
+		return Request{Attributes: map[ContextSelector]string{"source.address": address, "auth.identity.group": group}}
+	}
+	for i := 0; i < 10; i++ {
+		assert.Check(t, limiter.Check(request("10.0.0.1", "users")).Allowed)
+		clock.Advance(time.Second)
+	}
+
+	decision := limiter.Check(request("10.0.0.2", "users"))
+	assert.Check(t, !decision.Allowed)
+	assert.Equal(t, decision.LimitedBy.Name, "subnet")
+	assert.Equal(t, decision.RetryAfter, 50*time.Second)
+	assert.Check(t, limiter.Check(request("10.0.0.1", "admin")).Allowed)
+	assert.Check(t, limiter.Check(request("192.168.0.1", "users")).Allowed)
+	anonymous := Request{Attributes: map[ContextSelector]string{"source.address": "10.0.0.3"}}
+	assert.Equal(t, limiter.Check(anonymous).LimitedBy.Name, "subnet")
+
+	clock.Advance(50 * time.Second)
+	assert.Check(t, limiter.Check(request("10.0.0.1", "users")).Allowed)
+}
+
+func TestLimiter_Variables(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	route := gwc.CreateGateway("gw").CreateRoute("route")
+	route.AddPolicy(PolicySpec[RateLimitPolicy]{name: "rate-limit-policy", defaults: RateLimitPolicy{Limits: map[string]Limit{
+		"per-user": {
+			Counters: []ContextSelector{"auth.identity.username"},
+			Rates:    []Rate{{Limit: 2, Duration: 1, Unit: MinuteTimeUnit}},
+		},
+	}}})
+
+	limiter, err := NewRouteLimiter(route, RateLimitPolicyMerger, NewFakeClock(time.Time{}))
+	assert.NilError(t, err)
+
+	user := func(name string) Request {
+		return Request{Attributes: map[ContextSelector]string{"auth.identity.username": name}}
+	}
+	assert.Check(t, limiter.Check(user("alice")).Allowed)
+	assert.Check(t, limiter.Check(user("alice")).Allowed)
+	assert.Check(t, !limiter.Check(user("alice")).Allowed)
+	assert.Check(t, limiter.Check(user("bob")).Allowed)
+	for i := 0; i < 3; i++ {
+		assert.Check(t, limiter.Check(Request{}).Allowed)
+	}
+}
+
+func TestLimiter_SharedCounters(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw := gwc.CreateGateway("gw")
+	gw.CreateRoute("api")
+	gw.CreateRoute("web")
+	gw.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw", defaults: RateLimitPolicy{Limits: map[string]Limit{
+		"shared":    {Rates: []Rate{{Limit: 3, Duration: 1, Unit: MinuteTimeUnit}}, Scope: GatewayCounterScope},
+		"per-route": {Rates: []Rate{{Limit: 2, Duration: 1, Unit: MinuteTimeUnit}}},
+	}}})
+
+	limiter, err := NewLimiter(CompileLimits(&gwc, RateLimitPolicyMerger), NewFakeClock(time.Time{}))
+	assert.NilError(t, err)
+
+	api := Request{Route: RouteRef{Gateway: "gw", Route: "api", Kind: HTTPRouteKind}}
+	// HTTPRoutes are referred to with or without their kind.
+	web := Request{Route: RouteRef{Gateway: "gw", Route: "web"}}
+	assert.Check(t, limiter.Check(api).Allowed)
+	assert.Check(t, limiter.Check(api).Allowed)
+	assert.Equal(t, limiter.Check(api).LimitedBy.Name, "per-route")
+	assert.Check(t, limiter.Check(web).Allowed)
+	assert.Equal(t, limiter.Check(web).LimitedBy.Name, "shared")
+	assert.Check(t, limiter.Check(Request{Route: RouteRef{Gateway: "gw", Route: "other"}}).Allowed)
+}
+
+func TestLimiter_InvalidPattern(t *testing.T) {
+	_, err := NewLimiter([]CompiledLimit{{
+		Name:       "broken",
+		Conditions: []WhenCondition{{Selector: "source.address", Operator: MatchesOperator, Value: "("}},
+	}}, nil)
+	assert.ErrorContains(t, err, "limit broken")
+}
@@ -0,0 +1,136 @@
+package gw_policies_playground
+
+type RouteKind string
+
+const (
+	HTTPRouteKind RouteKind = "HTTPRoute"
+	GRPCRouteKind RouteKind = "GRPCRoute"
+	TCPRouteKind  RouteKind = "TCPRoute"
+	TLSRouteKind  RouteKind = "TLSRoute"
+)
+
+// RouteKindSupport is implemented by the policy types that only apply to some kinds of routes, e.g. auth needs a
+// request to act on, so it has nothing to do on a TCPRoute. Policy types that don't implement it apply to all of them.
+type RouteKindSupport interface {
+	SupportedRouteKinds() []RouteKind
+}
+
+func supportsRouteKind[T Policy](kind RouteKind) bool {
+	var policy T
+	support, ok := interface{}(policy).(RouteKindSupport)
+	if !ok {
+		return true
+	}
+	for _, supported := range support.SupportedRouteKinds() {
+		if supported == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// routeNode is implemented by the routes of all kinds under a Gateway.
+type routeNode[T Policy] interface {
+	kind() RouteKind
+	gateway() *Gateway[T]
+	routeName() string
+
+	// contributions returns the policies of the route and its ancestors, whether they apply to the route or not.
+	contributions() []policyContribution[T]
+
+	MergedPolicies(merger func(T, T) T) T
+}
+
+// applicableContributions expects the caller to hold the tree's lock. A route of a kind the policy type doesn't
+// support has none: policies attached to it, or inherited, are skipped. Nor are Direct policies attached to its
+// ancestors inherited.
+func applicableContributions[T Policy](route routeNode[T]) []policyContribution[T] {
+	if !supportsRouteKind[T](route.kind()) {
+		return nil
+	}
+	return inheritedContributions[T](route, route.contributions())
+}
+
+func (r *HttpRoute[T]) kind() RouteKind { return HTTPRouteKind }
+func (r *GrpcRoute[T]) kind() RouteKind { return GRPCRouteKind }
+func (r *TcpRoute[T]) kind() RouteKind  { return TCPRouteKind }
+func (r *TlsRoute[T]) kind() RouteKind  { return TLSRouteKind }
+
+// routeBase is what the routes of all kinds have in common: their gateway, their name and the policies attached to
+// them, along with how these are attached, detached and merged. Each kind of route embeds it next to its own fields.
+type routeBase[T Policy] struct {
+	// node is the route embedding the base, which the policies attached to it target.
+	node routeNode[T]
+
+	parent   *Gateway[T]
+	name     string
+	policies []PolicySpec[T]
+}
+
+func (r *routeBase[T]) gateway() *Gateway[T] { return r.parent }
+func (r *routeBase[T]) routeName() string    { return r.name }
+
+func (r *routeBase[T]) AddPolicy(policy PolicySpec[T]) {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	r.policies = append(r.policies, policy)
+	r.parent.parent.notify(r.node, Mutation{Kind: PolicyAttached, TargetKind: string(r.node.kind()), TargetName: r.name, Gateway: r.parent.name, Policy: policy.name})
+}
+
+func (r *routeBase[T]) RemovePolicy(name string) bool {
+	r.parent.parent.lock.Lock()
+	defer r.parent.parent.lock.Unlock()
+
+	var removed bool
+	if r.policies, removed = removePolicy(r.policies, name); removed {
+		r.parent.parent.notify(r.node, Mutation{Kind: PolicyDetached, TargetKind: string(r.node.kind()), TargetName: r.name, Gateway: r.parent.name, Policy: name})
+	}
+	return removed
+}
+
+// MergedPolicies is the zero T if the policy type doesn't support the kind of the route.
+func (r *routeBase[T]) MergedPolicies(merger func(T, T) T) T {
+	r.parent.parent.lock.RLock()
+	defer r.parent.parent.lock.RUnlock()
+
+	return r.mergedPolicies(merger)
+}
+
+// mergedPolicies expects the caller to hold the tree's lock.
+func (r *routeBase[T]) mergedPolicies(merger func(T, T) T) T {
+	return foldContributions(applicableContributions(r.node), merger)
+}
+
+func (r *routeBase[T]) contributions() []policyContribution[T] {
+	contributions := addContributions(nil, r.node, r.policies)
+	contributions = addContributions(contributions, r.parent, r.parent.policies)
+	return addContributions(contributions, r.parent.parent, r.parent.parent.policies)
+}
+
+// routesBeneath expects the caller to hold the tree's lock.
+func routesBeneath[T Policy](target interface{}) []routeNode[T] {
+	var routes []routeNode[T]
+	switch target := target.(type) {
+	case *GatewayClass[T]:
+		for gw := range target.gateways {
+			routes = append(routes, routesBeneath[T](gw)...)
+		}
+	case *Gateway[T]:
+		for route := range target.routes {
+			routes = append(routes, route)
+		}
+		for route := range target.grpcRoutes {
+			routes = append(routes, route)
+		}
+		for route := range target.tcpRoutes {
+			routes = append(routes, route)
+		}
+		for route := range target.tlsRoutes {
+			routes = append(routes, route)
+		}
+	case routeNode[T]:
+		routes = append(routes, target)
+	}
+	return routes
+}
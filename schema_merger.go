@@ -0,0 +1,206 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"sigs.k8s.io/yaml"
+)
+
+// UnstructuredPolicy is a policy document as decoded from JSON or YAML, for the mergers that don't need a Go type.
+type UnstructuredPolicy map[string]interface{}
+
+const (
+	ListTypeAtomic = "atomic"
+	ListTypeSet    = "set"
+	ListTypeMap    = "map"
+
+	MapTypeAtomic   = "atomic"
+	MapTypeGranular = "granular"
+)
+
+// Schema is the part of an OpenAPI v3 schema, as found in a CRD, that SchemaMerger relies on: the structure of the
+// document and the `x-kubernetes-list-type`, `x-kubernetes-list-map-keys` and `x-kubernetes-map-type` markers.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"-"`
+
+	ListType    string   `json:"x-kubernetes-list-type,omitempty"`
+	ListMapKeys []string `json:"x-kubernetes-list-map-keys,omitempty"`
+	MapType     string   `json:"x-kubernetes-map-type,omitempty"`
+}
+
+// UnmarshalJSON handles additionalProperties being either a schema or a boolean, the latter telling nothing about the
+// values.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schema Schema
+	var decoded struct {
+		schema
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*s = Schema(decoded.schema)
+	if len(decoded.AdditionalProperties) > 0 && decoded.AdditionalProperties[0] == '{' {
+		s.AdditionalProperties = &Schema{}
+		return json.Unmarshal(decoded.AdditionalProperties, s.AdditionalProperties)
+	}
+	return nil
+}
+
+// Property returns the schema at the path of properties, e.g. `spec`, `defaults`, or nil if there's none.
+func (s *Schema) Property(path ...string) *Schema {
+	current := s
+	for _, name := range path {
+		if current == nil {
+			return nil
+		}
+		current = current.field(name)
+	}
+	return current
+}
+
+func (s *Schema) field(name string) *Schema {
+	if s == nil {
+		return nil
+	}
+	if property, exists := s.Properties[name]; exists {
+		return property
+	}
+	return s.AdditionalProperties
+}
+
+// SchemaFromCRD reads the openAPIV3Schema of the version of a CRD, from its YAML or JSON.
+func SchemaFromCRD(data []byte, version string) (*Schema, error) {
+	raw, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var crd struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Versions []struct {
+				Name   string `json:"name"`
+				Schema struct {
+					OpenAPIV3Schema *Schema `json:"openAPIV3Schema"`
+				} `json:"schema"`
+			} `json:"versions"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &crd); err != nil {
+		return nil, err
+	}
+	if crd.Kind != "CustomResourceDefinition" {
+		return nil, fmt.Errorf("expected a CustomResourceDefinition, got %q", crd.Kind)
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version {
+			if v.Schema.OpenAPIV3Schema == nil {
+				return nil, fmt.Errorf("version %s has no openAPIV3Schema", version)
+			}
+			return v.Schema.OpenAPIV3Schema, nil
+		}
+	}
+	return nil, fmt.Errorf("no version %s", version)
+}
+
+// SchemaMerger returns a merger of UnstructuredPolicy documents following the schema, p1 taking precedence over p2:
+//   - objects are merged field by field, unless their `x-kubernetes-map-type` is `atomic`;
+//   - lists whose `x-kubernetes-list-type` is `map` are merged entry by entry, entries being identified by the values
+//     of their `x-kubernetes-list-map-keys`, and the ones of p2 that aren't in p1 appended;
+//   - lists whose `x-kubernetes-list-type` is `set` are the union of both, in order;
+//   - any other value, lists included as they're `atomic` by default, is the one of p1 if set.
+//
+// Objects the schema doesn't describe are merged field by field.
+func SchemaMerger(schema *Schema) func(UnstructuredPolicy, UnstructuredPolicy) UnstructuredPolicy {
+	return func(p1, p2 UnstructuredPolicy) UnstructuredPolicy {
+		merged, _ := mergeWithSchema(schema, map[string]interface{}(p1), map[string]interface{}(p2)).(map[string]interface{})
+		return merged
+	}
+}
+
+func mergeWithSchema(schema *Schema, v1, v2 interface{}) interface{} {
+	if v1 == nil {
+		return v2
+	}
+	if v2 == nil {
+		return v1
+	}
+	switch v1 := v1.(type) {
+	case map[string]interface{}:
+		v2, ok := v2.(map[string]interface{})
+		if !ok || (schema != nil && schema.MapType == MapTypeAtomic) {
+			return v1
+		}
+		merged := make(map[string]interface{}, len(v1)+len(v2))
+		for key, value := range v2 {
+			merged[key] = value
+		}
+		for key, value := range v1 {
+			merged[key] = mergeWithSchema(schema.field(key), value, merged[key])
+		}
+		return merged
+	case []interface{}:
+		v2, ok := v2.([]interface{})
+		if !ok || schema == nil {
+			return v1
+		}
+		switch schema.ListType {
+		case ListTypeMap:
+			return mergeListMap(schema.Items, schema.ListMapKeys, v1, v2)
+		case ListTypeSet:
+			merged := append([]interface{}{}, v1...)
+			for _, value := range v2 {
+				if !containsValue(merged, value) {
+					merged = append(merged, value)
+				}
+			}
+			return merged
+		}
+	}
+	return v1
+}
+
+func mergeListMap(items *Schema, keys []string, l1, l2 []interface{}) []interface{} {
+	identity := func(entry interface{}) string {
+		object, _ := entry.(map[string]interface{})
+		id := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			id = append(id, object[key])
+		}
+		raw, _ := json.Marshal(id)
+		return string(raw)
+	}
+
+	entries := make(map[string]interface{}, len(l2))
+	for _, entry := range l2 {
+		entries[identity(entry)] = entry
+	}
+	merged := make([]interface{}, 0, len(l1)+len(l2))
+	seen := make(map[string]bool, len(l1))
+	for _, entry := range l1 {
+		id := identity(entry)
+		seen[id] = true
+		merged = append(merged, mergeWithSchema(items, entry, entries[id]))
+	}
+	for _, entry := range l2 {
+		if id := identity(entry); !seen[id] {
+			seen[id] = true
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, existing := range values {
+		if reflect.DeepEqual(existing, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"sigs.k8s.io/yaml"
+)
+
+const testTrafficPolicyCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: trafficpolicies.example.com
+spec:
+  group: example.com
+  names:
+    kind: TrafficPolicy
+  versions:
+  - name: v1alpha1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              defaults:
+                type: object
+                properties:
+                  timeouts:
+                    type: object
+                    x-kubernetes-map-type: atomic
+                    properties:
+                      connect:
+                        type: string
+                      request:
+                        type: string
+                  headers:
+                    type: object
+                    additionalProperties:
+                      type: string
+                  retries:
+                    type: array
+                    x-kubernetes-list-type: map
+                    x-kubernetes-list-map-keys: [name]
+                    items:
+                      type: object
+                      properties:
+                        name:
+                          type: string
+                        attempts:
+                          type: integer
+                        codes:
+                          type: array
+                          x-kubernetes-list-type: set
+                          items:
+                            type: integer
+                  methods:
+                    type: array
+                    items:
+                      type: string
+`
+
+func testUnstructuredPolicy(t *testing.T, doc string) UnstructuredPolicy {
+	var policy UnstructuredPolicy
+	assert.NilError(t, yaml.Unmarshal([]byte(doc), &policy))
+	return policy
+}
+
+func TestSchemaMerger(t *testing.T) {
+	crd, err := SchemaFromCRD([]byte(testTrafficPolicyCRD), "v1alpha1")
+	assert.NilError(t, err)
+	schema := crd.Property("spec", "defaults")
+	assert.Equal(t, schema.Property("retries").ListType, ListTypeMap)
+
+	gwc := NewGatewayClass[UnstructuredPolicy]("gwc1")
+	gw := gwc.CreateGateway("gw")
+	route := gw.CreateRoute("route")
+
+	gw.AddPolicy(NewPolicySpec("gw", testUnstructuredPolicy(t, `
+timeouts: {connect: 1s, request: 10s}
+headers: {x-team: ops, x-tier: free}
+retries:
+- {name: upstream, attempts: 3, codes: [502, 503]}
+- {name: reset, attempts: 1}
+methods: [GET, POST]
+`), nil))
+	route.AddPolicy(NewPolicySpec("route", testUnstructuredPolicy(t, `
+timeouts: {request: 30s}
+headers: {x-tier: paid}
+retries:
+- {name: upstream, attempts: 5, codes: [504, 502]}
+methods: [GET]
+`), nil))
+
+	assert.DeepEqual(t, route.MergedPolicies(SchemaMerger(schema)), testUnstructuredPolicy(t, `
+timeouts: {request: 30s}
+headers: {x-team: ops, x-tier: paid}
+retries:
+- {name: upstream, attempts: 5, codes: [504, 502, 503]}
+- {name: reset, attempts: 1}
+methods: [GET]
+`))
+}
+
+func TestSchemaFromCRD_Errors(t *testing.T) {
+	_, err := SchemaFromCRD([]byte(testTrafficPolicyCRD), "v1")
+	assert.ErrorContains(t, err, "no version v1")
+	_, err = SchemaFromCRD([]byte("kind: ConfigMap"), "v1")
+	assert.ErrorContains(t, err, `expected a CustomResourceDefinition, got "ConfigMap"`)
+}
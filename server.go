@@ -0,0 +1,224 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Server exposes a hierarchy over HTTP, for tools that drive the playground without linking it:
+//
+//	GET    /snapshot                              the whole tree, as ExportSnapshot writes it
+//	PUT    /gateways/{gw}                         creates a gateway
+//	PUT    /routes/{gw}/{route}                   creates an HTTP route
+//	GET    /routes/{gw}/{route}/effective         the effective policy of a route, and the policies it comes from
+//	POST   {target}/policies                      attaches a policy, e.g. {"name": "p", "defaults": {...}}
+//	DELETE {target}/policies/{name}               detaches the policies of that name
+//
+// where {target} is empty for the class, /gateways/{gw} or /routes/{gw}/{route}. Names are path segments, so a `/`
+// within them has to be escaped as `%2F`. Errors are returned as plain text.
+type Server[T Policy] struct {
+	class  *GatewayClass[T]
+	merger func(T, T) T
+
+	// lock makes the lookups and the mutations of a request atomic, with regard to the other requests.
+	lock sync.Mutex
+}
+
+func NewServer[T Policy](class *GatewayClass[T], merger func(T, T) T) *Server[T] {
+	return &Server[T]{class: class, merger: merger}
+}
+
+// EffectivePolicy is the body of the response to `GET /routes/{gw}/{route}/effective`.
+type EffectivePolicy[T Policy] struct {
+	Gateway string `json:"gateway"`
+	Route   string `json:"route"`
+	Policy  T      `json:"policy"`
+
+	// Provenance are the policies affecting the route, from the highest precedence to the lowest.
+	Provenance []AffectingPolicy `json:"provenance"`
+}
+
+// policyTarget is a level of the hierarchy policies attach to.
+type policyTarget[T Policy] interface {
+	AddPolicy(PolicySpec[T])
+	RemovePolicy(string) bool
+}
+
+func (s *Server[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments, err := pathSegments(r.URL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var target policyTarget[T] = s.class
+	var gw *Gateway[T]
+	var route *HttpRoute[T]
+	switch {
+	case len(segments) >= 2 && segments[0] == "gateways":
+		var exists bool
+		gw, exists = s.class.Gateway(segments[1])
+		if len(segments) == 2 {
+			s.createGateway(w, r, segments[1], exists)
+			return
+		}
+		if !exists {
+			http.Error(w, fmt.Sprintf("no gateway %s", segments[1]), http.StatusNotFound)
+			return
+		}
+		target, segments = gw, segments[2:]
+	case len(segments) >= 3 && segments[0] == "routes":
+		var exists bool
+		if gw, exists = s.class.Gateway(segments[1]); !exists {
+			http.Error(w, fmt.Sprintf("no gateway %s", segments[1]), http.StatusNotFound)
+			return
+		}
+		route, exists = gw.Route(segments[2])
+		if len(segments) == 3 {
+			s.createRoute(w, r, gw, segments[2], exists)
+			return
+		}
+		if !exists {
+			http.Error(w, fmt.Sprintf("no route %s on gateway %s", segments[2], segments[1]), http.StatusNotFound)
+			return
+		}
+		target, segments = route, segments[3:]
+	}
+
+	switch {
+	case len(segments) == 1 && segments[0] == "snapshot" && gw == nil:
+		s.snapshot(w, r)
+	case len(segments) == 1 && segments[0] == "effective" && route != nil:
+		s.effective(w, r, route)
+	case len(segments) == 1 && segments[0] == "policies":
+		s.attach(w, r, target)
+	case len(segments) == 2 && segments[0] == "policies":
+		s.detach(w, r, target, segments[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server[T]) createGateway(w http.ResponseWriter, r *http.Request, name string, exists bool) {
+	if !allowMethod(w, r, http.MethodPut) {
+		return
+	}
+	if exists {
+		http.Error(w, fmt.Sprintf("gateway %s already exists", name), http.StatusConflict)
+		return
+	}
+	s.class.CreateGateway(name)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server[T]) createRoute(w http.ResponseWriter, r *http.Request, gw *Gateway[T], name string, exists bool) {
+	if !allowMethod(w, r, http.MethodPut) {
+		return
+	}
+	if exists {
+		http.Error(w, fmt.Sprintf("route %s already exists on gateway %s", name, gw.name), http.StatusConflict)
+		return
+	}
+	gw.CreateRoute(name)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server[T]) snapshot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	snapshot, err := s.class.ExportSnapshot()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(snapshot)
+}
+
+func (s *Server[T]) effective(w http.ResponseWriter, r *http.Request, route *HttpRoute[T]) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	// Both off the same snapshot of the tree.
+	s.class.lock.RLock()
+	effective := EffectivePolicy[T]{
+		Gateway:    route.parent.name,
+		Route:      route.name,
+		Policy:     route.mergedPolicies(s.merger),
+		Provenance: affectingPolicies[T](route, route.contributions(), []routeNode[T]{route}, s.merger),
+	}
+	s.class.lock.RUnlock()
+	writeJSON(w, effective)
+}
+
+func (s *Server[T]) attach(w http.ResponseWriter, r *http.Request, target policyTarget[T]) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	var policy policySnapshot[T]
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, fmt.Sprintf("invalid policy: %v", err), http.StatusBadRequest)
+		return
+	}
+	if policy.Name == "" {
+		http.Error(w, "invalid policy: a name is required", http.StatusBadRequest)
+		return
+	}
+	target.AddPolicy(policy.spec())
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server[T]) detach(w http.ResponseWriter, r *http.Request, target policyTarget[T], name string) {
+	if !allowMethod(w, r, http.MethodDelete) {
+		return
+	}
+	if !target.RemovePolicy(name) {
+		http.Error(w, fmt.Sprintf("no policy %s", name), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pathSegments splits the path, unescaping each segment on its own, so that names can hold an escaped `/`.
+func pathSegments(u *url.URL) ([]string, error) {
+	path := strings.Trim(u.EscapedPath(), "/")
+	if path == "" {
+		return nil, nil
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = unescaped
+	}
+	return segments, nil
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
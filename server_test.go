@@ -0,0 +1,80 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func testRequest(t *testing.T, server *httptest.Server, method, path, body string) (int, string) {
+	request, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	assert.NilError(t, err)
+	response, err := server.Client().Do(request)
+	assert.NilError(t, err)
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	assert.NilError(t, err)
+	return response.StatusCode, string(data)
+}
+
+func TestServer(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	server := httptest.NewServer(NewServer(&gwc, RateLimitPolicyMerger))
+	defer server.Close()
+
+	for _, step := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPut, "/gateways/gateway-system%2Fgw", "", http.StatusCreated},
+		{http.MethodPut, "/gateways/gateway-system%2Fgw", "", http.StatusConflict},
+		{http.MethodPut, "/routes/gateway-system%2Fgw/toystore", "", http.StatusCreated},
+		{http.MethodPut, "/routes/missing/toystore", "", http.StatusNotFound},
+		{http.MethodPost, "/policies", `{"name": "class", "defaults": {"limits": {"global": {"rates": [{"limit": 1000, "duration": 1, "unit": "minute"}]}}}}`, http.StatusCreated},
+		{http.MethodPost, "/gateways/gateway-system%2Fgw/policies", `{"name": "gw", "overrides": {"limits": {"subnet": {"rates": [{"limit": 10, "duration": 1, "unit": "minute"}]}}}}`, http.StatusCreated},
+		{http.MethodPost, "/routes/gateway-system%2Fgw/toystore/policies", `{"name": "route", "defaults": {"limits": {"subnet": {"rates": [{"limit": 5, "duration": 1, "unit": "minute"}]}}}}`, http.StatusCreated},
+		{http.MethodPost, "/routes/gateway-system%2Fgw/toystore/policies", `{"defaults": {}}`, http.StatusBadRequest},
+		{http.MethodPost, "/routes/gateway-system%2Fgw/toystore/policies", `{`, http.StatusBadRequest},
+		{http.MethodPost, "/routes/gateway-system%2Fgw/toystore/policies", `{"name": "sideways", "class": "Sideways"}`, http.StatusBadRequest},
+		{http.MethodGet, "/routes/gateway-system%2Fgw/toystore/policies", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/gateways/gateway-system%2Fgw/effective", "", http.StatusNotFound},
+	} {
+		status, body := testRequest(t, server, step.method, step.path, step.body)
+		assert.Equal(t, status, step.status, "%s %s: %s", step.method, step.path, body)
+	}
+
+	status, body := testRequest(t, server, http.MethodGet, "/routes/gateway-system%2Fgw/toystore/effective", "")
+	assert.Equal(t, status, http.StatusOK)
+	var effective EffectivePolicy[RateLimitPolicy]
+	assert.NilError(t, json.Unmarshal([]byte(body), &effective))
+	assert.Equal(t, effective.Gateway, "gateway-system/gw")
+	assert.Equal(t, effective.Policy.Limits["subnet"].Rates[0].Limit, 10)
+	assert.Equal(t, effective.Policy.Limits["global"].Rates[0].Limit, 1000)
+	assert.DeepEqual(t, effective.Provenance, []AffectingPolicy{
+		{Name: "gw", TargetKind: "Gateway", TargetName: "gateway-system/gw", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationOverride},
+		{Name: "route", TargetKind: "HTTPRoute", TargetName: "toystore", Class: InheritedPolicyClass, Participation: ParticipationShadowed},
+		{Name: "class", TargetKind: "GatewayClass", TargetName: "gwc", Inherited: true, Class: InheritedPolicyClass, Participation: ParticipationDefault},
+	})
+
+	status, _ = testRequest(t, server, http.MethodDelete, "/gateways/gateway-system%2Fgw/policies/gw", "")
+	assert.Equal(t, status, http.StatusNoContent)
+	status, _ = testRequest(t, server, http.MethodDelete, "/gateways/gateway-system%2Fgw/policies/gw", "")
+	assert.Equal(t, status, http.StatusNotFound)
+
+	_, body = testRequest(t, server, http.MethodGet, "/routes/gateway-system%2Fgw/toystore/effective", "")
+	assert.NilError(t, json.Unmarshal([]byte(body), &effective))
+	assert.Equal(t, effective.Policy.Limits["subnet"].Rates[0].Limit, 5)
+
+	status, body = testRequest(t, server, http.MethodGet, "/snapshot", "")
+	assert.Equal(t, status, http.StatusOK)
+	imported, err := ImportSnapshot[RateLimitPolicy]([]byte(body))
+	assert.NilError(t, err)
+	gw, _ := imported.Gateway("gateway-system/gw")
+	route, _ := gw.Route("toystore")
+	assert.DeepEqual(t, route.MergedPolicies(RateLimitPolicyMerger), effective.Policy)
+}
@@ -0,0 +1,222 @@
+package gw_policies_playground
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// classSnapshot is the JSON representation of a GatewayClass tree. Objects are sorted by name, while the policies keep
+// the order they were attached in, i.e. their precedence. Backends are referred to by name.
+type classSnapshot[T Policy] struct {
+	Name     string               `json:"name"`
+	Policies []policySnapshot[T]  `json:"policies,omitempty"`
+	Services []serviceSnapshot[T] `json:"services,omitempty"`
+	Gateways []gatewaySnapshot[T] `json:"gateways,omitempty"`
+}
+
+type gatewaySnapshot[T Policy] struct {
+	Name       string                 `json:"name"`
+	Listeners  []Listener             `json:"listeners,omitempty"`
+	Policies   []policySnapshot[T]    `json:"policies,omitempty"`
+	Routes     []httpRouteSnapshot[T] `json:"routes,omitempty"`
+	GrpcRoutes []grpcRouteSnapshot[T] `json:"grpcRoutes,omitempty"`
+	TcpRoutes  []l4RouteSnapshot[T]   `json:"tcpRoutes,omitempty"`
+	TlsRoutes  []l4RouteSnapshot[T]   `json:"tlsRoutes,omitempty"`
+}
+
+type httpRouteSnapshot[T Policy] struct {
+	Name        string              `json:"name"`
+	Hostnames   []string            `json:"hostnames,omitempty"`
+	SectionName string              `json:"sectionName,omitempty"`
+	Rules       []ruleSnapshot      `json:"rules,omitempty"`
+	Policies    []policySnapshot[T] `json:"policies,omitempty"`
+}
+
+type ruleSnapshot struct {
+	Name        string   `json:"name,omitempty"`
+	BackendRefs []string `json:"backendRefs,omitempty"`
+}
+
+type grpcRouteSnapshot[T Policy] struct {
+	Name        string              `json:"name"`
+	Hostnames   []string            `json:"hostnames,omitempty"`
+	SectionName string              `json:"sectionName,omitempty"`
+	Matches     []GrpcMethodMatch   `json:"matches,omitempty"`
+	Policies    []policySnapshot[T] `json:"policies,omitempty"`
+}
+
+// l4RouteSnapshot is either a TcpRoute or a TlsRoute, only the latter having hostnames.
+type l4RouteSnapshot[T Policy] struct {
+	Name      string              `json:"name"`
+	Hostnames []string            `json:"hostnames,omitempty"`
+	Policies  []policySnapshot[T] `json:"policies,omitempty"`
+}
+
+type serviceSnapshot[T Policy] struct {
+	Name     string              `json:"name"`
+	Policies []policySnapshot[T] `json:"policies,omitempty"`
+}
+
+type policySnapshot[T Policy] struct {
+	Name      string      `json:"name"`
+	Defaults  T           `json:"defaults"`
+	Overrides T           `json:"overrides"`
+	Class     PolicyClass `json:"class,omitempty"`
+}
+
+// ExportSnapshot serialises the whole tree as JSON, along with every PolicySpec attached to it, for ImportSnapshot to
+// rebuild it. Policies are serialised as their own type is, so T has to round trip through JSON, as AuthPolicy does
+// with its removals. Constraints are code rather than data, so they aren't part of the snapshot.
+func (gwc *GatewayClass[T]) ExportSnapshot() ([]byte, error) {
+	gwc.lock.RLock()
+	defer gwc.lock.RUnlock()
+
+	snapshot := classSnapshot[T]{Name: gwc.name, Policies: policySnapshots(gwc.policies)}
+	for _, s := range sortedByName(gwc.services, func(s *Service[T]) string { return s.name }) {
+		snapshot.Services = append(snapshot.Services, serviceSnapshot[T]{Name: s.name, Policies: policySnapshots(s.policies)})
+	}
+	for _, gw := range sortedByName(gwc.gateways, func(gw *Gateway[T]) string { return gw.name }) {
+		g := gatewaySnapshot[T]{
+			Name:      gw.name,
+			Listeners: gw.listeners,
+			Policies:  policySnapshots(gw.policies),
+		}
+		for _, r := range sortedByName(gw.routes, func(r *HttpRoute[T]) string { return r.name }) {
+			route := httpRouteSnapshot[T]{
+				Name:        r.name,
+				Hostnames:   r.hostnames,
+				SectionName: r.sectionName,
+				Policies:    policySnapshots(r.policies),
+			}
+			for _, rule := range r.rules {
+				backends := make([]string, 0, len(rule.BackendRefs))
+				for _, backend := range rule.BackendRefs {
+					backends = append(backends, backend.name)
+				}
+				route.Rules = append(route.Rules, ruleSnapshot{Name: rule.Name, BackendRefs: backends})
+			}
+			g.Routes = append(g.Routes, route)
+		}
+		for _, r := range sortedByName(gw.grpcRoutes, func(r *GrpcRoute[T]) string { return r.name }) {
+			g.GrpcRoutes = append(g.GrpcRoutes, grpcRouteSnapshot[T]{
+				Name:        r.name,
+				Hostnames:   r.hostnames,
+				SectionName: r.sectionName,
+				Matches:     r.matches,
+				Policies:    policySnapshots(r.policies),
+			})
+		}
+		for _, r := range sortedByName(gw.tcpRoutes, func(r *TcpRoute[T]) string { return r.name }) {
+			g.TcpRoutes = append(g.TcpRoutes, l4RouteSnapshot[T]{Name: r.name, Policies: policySnapshots(r.policies)})
+		}
+		for _, r := range sortedByName(gw.tlsRoutes, func(r *TlsRoute[T]) string { return r.name }) {
+			g.TlsRoutes = append(g.TlsRoutes, l4RouteSnapshot[T]{Name: r.name, Hostnames: r.hostnames, Policies: policySnapshots(r.policies)})
+		}
+		snapshot.Gateways = append(snapshot.Gateways, g)
+	}
+	return json.Marshal(snapshot)
+}
+
+// ImportSnapshot rebuilds a tree out of the output of ExportSnapshot.
+func ImportSnapshot[T Policy](data []byte) (*GatewayClass[T], error) {
+	var snapshot classSnapshot[T]
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+
+	gwc := NewGatewayClass[T](snapshot.Name)
+	for _, p := range snapshot.Policies {
+		gwc.AddPolicy(p.spec())
+	}
+	services := make(map[string]*Service[T], len(snapshot.Services))
+	for _, s := range snapshot.Services {
+		if _, exists := services[s.Name]; exists {
+			return nil, fmt.Errorf("service %s: duplicated", s.Name)
+		}
+		service := gwc.CreateService(s.Name)
+		for _, p := range s.Policies {
+			service.AddPolicy(p.spec())
+		}
+		services[s.Name] = service
+	}
+	for _, g := range snapshot.Gateways {
+		gw := gwc.CreateGateway(g.Name)
+		for _, listener := range g.Listeners {
+			gw.AddListener(listener)
+		}
+		for _, p := range g.Policies {
+			gw.AddPolicy(p.spec())
+		}
+		for _, r := range g.Routes {
+			route := gw.CreateRoute(r.Name)
+			if len(r.Hostnames) > 0 {
+				route.SetHostnames(r.Hostnames...)
+			}
+			route.SetSectionName(r.SectionName)
+			for _, rule := range r.Rules {
+				var backends []*Service[T]
+				for _, name := range rule.BackendRefs {
+					backend, exists := services[name]
+					if !exists {
+						return nil, fmt.Errorf("gateway %s: route %s: no service %s", g.Name, r.Name, name)
+					}
+					backends = append(backends, backend)
+				}
+				route.AddRule(rule.Name, backends...)
+			}
+			for _, p := range r.Policies {
+				route.AddPolicy(p.spec())
+			}
+		}
+		for _, r := range g.GrpcRoutes {
+			route := gw.CreateGrpcRoute(r.Name, r.Matches...)
+			if len(r.Hostnames) > 0 {
+				route.SetHostnames(r.Hostnames...)
+			}
+			route.SetSectionName(r.SectionName)
+			for _, p := range r.Policies {
+				route.AddPolicy(p.spec())
+			}
+		}
+		for _, r := range g.TcpRoutes {
+			route := gw.CreateTcpRoute(r.Name)
+			for _, p := range r.Policies {
+				route.AddPolicy(p.spec())
+			}
+		}
+		for _, r := range g.TlsRoutes {
+			route := gw.CreateTlsRoute(r.Name, r.Hostnames...)
+			for _, p := range r.Policies {
+				route.AddPolicy(p.spec())
+			}
+		}
+	}
+	return &gwc, nil
+}
+
+func policySnapshots[T Policy](policies []PolicySpec[T]) []policySnapshot[T] {
+	var snapshots []policySnapshot[T]
+	for _, policy := range policies {
+		snapshots = append(snapshots, policySnapshot[T]{
+			Name:      policy.name,
+			Defaults:  policy.defaults,
+			Overrides: policy.overrides,
+			Class:     policy.class,
+		})
+	}
+	return snapshots
+}
+
+func (p policySnapshot[T]) spec() PolicySpec[T] {
+	return NewPolicySpec(p.Name, p.Defaults, p.Overrides).WithClass(p.Class)
+}
+
+func sortedByName[K comparable](objects map[K]void, name func(K) string) []K {
+	sorted := make([]K, 0, len(objects))
+	for object := range objects {
+		sorted = append(sorted, object)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return name(sorted[i]) < name(sorted[j]) })
+	return sorted
+}
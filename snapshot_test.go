@@ -0,0 +1,70 @@
+package gw_policies_playground
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	removal := testAuthPolicySpec2
+	removal.Removals = []string{"identity.friends", "patterns.api-route"}
+
+	gwc := NewGatewayClass[AuthPolicy]("gwc")
+	gwc.AddPolicy(NewPolicySpec("class", testAuthPolicySpec1, AuthPolicy{}))
+	backend := gwc.CreateService("toystore")
+	backend.AddPolicy(NewPolicySpec("backend", testAuthPolicySpec2, AuthPolicy{}))
+	gw := gwc.CreateGateway("gw")
+	gw.AddListener(Listener{Name: "api", Hostname: "*.toystore.io"})
+	gw.AddPolicy(NewPolicySpec("gw", AuthPolicy{}, removal))
+	gw.AddPolicy(NewPolicySpec("gw-direct", testAuthPolicySpec2, AuthPolicy{}).WithClass(DirectPolicyClass))
+	route := gw.CreateRoute("api")
+	route.SetHostnames("api.toystore.io")
+	route.SetSectionName("api")
+	route.AddRule("all", backend)
+	route.AddPolicy(NewPolicySpec("route", testAuthPolicySpec1, AuthPolicy{}))
+	gw.CreateRoute("bare")
+	gw.CreateGrpcRoute("grpc", GrpcMethodMatch{Service: "toystore.Toys", Method: "List"}).SetHostnames("grpc.toystore.io")
+	gw.CreateTcpRoute("tcp").AddPolicy(NewPolicySpec("tcp", testAuthPolicySpec1, AuthPolicy{}))
+	gw.CreateTlsRoute("tls", "tls.toystore.io")
+	gwc.CreateGateway("other").CreateRoute("api")
+
+	data, err := gwc.ExportSnapshot()
+	assert.NilError(t, err)
+	imported, err := ImportSnapshot[AuthPolicy](data)
+	assert.NilError(t, err)
+
+	exported, err := imported.ExportSnapshot()
+	assert.NilError(t, err)
+	assert.Equal(t, string(exported), string(data))
+
+	importedGw, _ := imported.Gateway("gw")
+	importedRoute, _ := importedGw.Route("api")
+	importedBackend, _ := imported.Service("toystore")
+	assert.DeepEqual(t, importedRoute.MergedPolicies(AuthPolicyMerger), route.MergedPolicies(AuthPolicyMerger))
+	assert.DeepEqual(t, importedRoute.BackendMergedPolicies(importedBackend, AuthPolicyMerger), route.BackendMergedPolicies(backend, AuthPolicyMerger))
+	assert.DeepEqual(t, importedRoute.Hosts(), []string{"api.toystore.io"})
+	assert.Equal(t, len(DiffEffectivePolicies(&gwc, imported, AuthPolicyMerger).Unchanged), 6)
+	assert.Check(t, DiffEffectivePolicies(&gwc, imported, AuthPolicyMerger).Empty())
+}
+
+func TestSnapshot_UnknownBackend(t *testing.T) {
+	_, err := ImportSnapshot[AuthPolicy]([]byte(`{"name":"gwc","gateways":[{"name":"gw","routes":[{"name":"api","rules":[{"backendRefs":["missing"]}]}]}]}`))
+	assert.Error(t, err, "gateway gw: route api: no service missing")
+}
+
+func TestSnapshot_UnknownPolicyClass(t *testing.T) {
+	_, err := ImportSnapshot[AuthPolicy]([]byte(`{"name":"gwc","policies":[{"name":"class","class":"Sideways"}]}`))
+	assert.Error(t, err, `unknown policy class "Sideways"`)
+}
+
+func TestSnapshot_V1beta2Removals(t *testing.T) {
+	gwc := NewGatewayClass[AuthPolicyV1beta2]("gwc")
+	gwc.AddPolicy(NewPolicySpec("class", AuthPolicyV1beta2{}, AuthPolicyV1beta2{Removals: []string{"authentication.anonymous"}}))
+
+	data, err := gwc.ExportSnapshot()
+	assert.NilError(t, err)
+	imported, err := ImportSnapshot[AuthPolicyV1beta2](data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, imported.policies[0].overrides, AuthPolicyV1beta2{Removals: []string{"authentication.anonymous"}})
+}
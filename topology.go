@@ -0,0 +1,239 @@
+package gw_policies_playground
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/types"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// Topology is the hierarchy of policies built out of Gateway API objects and the policy CRs targeting them.
+type Topology[T Policy] struct {
+	Classes  map[string]*GatewayClass[T]
+	Gateways map[types.NamespacedName]*Gateway[T]
+
+	// Routes holds one HttpRoute per parent Gateway of an HTTPRoute object, and so do GRPCRoutes, TCPRoutes and
+	// TLSRoutes.
+	Routes     map[types.NamespacedName][]*HttpRoute[T]
+	GRPCRoutes map[types.NamespacedName][]*GrpcRoute[T]
+	TCPRoutes  map[types.NamespacedName][]*TcpRoute[T]
+	TLSRoutes  map[types.NamespacedName][]*TlsRoute[T]
+
+	// Unattached are the policies whose target doesn't exist.
+	Unattached []*PolicyCR[T]
+
+	attachments map[*PolicyCR[T]][]policyPosition
+}
+
+// NewTopology builds the hierarchy, naming gateways and routes after their `namespace/name`. Policies are attached
+// from the oldest to the most recent.
+func NewTopology[T Policy](gateways []gatewayapi.Gateway, routes []gatewayapi.HTTPRoute, grpcRoutes []gatewayapi.GRPCRoute, tcpRoutes []gatewayapi.TCPRoute, tlsRoutes []gatewayapi.TLSRoute, policies []PolicyCR[T]) *Topology[T] {
+	topology := &Topology[T]{
+		Classes:    make(map[string]*GatewayClass[T]),
+		Gateways:   make(map[types.NamespacedName]*Gateway[T]),
+		Routes:     make(map[types.NamespacedName][]*HttpRoute[T]),
+		GRPCRoutes: make(map[types.NamespacedName][]*GrpcRoute[T]),
+		TCPRoutes:  make(map[types.NamespacedName][]*TcpRoute[T]),
+		TLSRoutes:  make(map[types.NamespacedName][]*TlsRoute[T]),
+
+		attachments: make(map[*PolicyCR[T]][]policyPosition),
+	}
+
+	for i := range gateways {
+		gateway := &gateways[i]
+		className := string(gateway.Spec.GatewayClassName)
+		gwc, exists := topology.Classes[className]
+		if !exists {
+			class := NewGatewayClass[T](className)
+			gwc = &class
+			topology.Classes[className] = gwc
+		}
+		key := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+		gw := gwc.CreateGateway(key.String())
+		for _, listener := range gateway.Spec.Listeners {
+			var hostname string
+			if listener.Hostname != nil {
+				hostname = string(*listener.Hostname)
+			}
+			gw.AddListener(Listener{Name: string(listener.Name), Hostname: hostname})
+		}
+		topology.Gateways[key] = gw
+	}
+
+	for i := range routes {
+		route := &routes[i]
+		key := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		var hostnames []string
+		for _, hostname := range route.Spec.Hostnames {
+			hostnames = append(hostnames, string(hostname))
+		}
+		for _, parentRef := range route.Spec.ParentRefs {
+			for _, gw := range topology.parentGateways(route.Namespace, []gatewayapi.ParentReference{parentRef}) {
+				r := gw.CreateRoute(key.String())
+				r.SetHostnames(hostnames...)
+				if parentRef.SectionName != nil {
+					r.SetSectionName(string(*parentRef.SectionName))
+				}
+				topology.Routes[key] = append(topology.Routes[key], r)
+			}
+		}
+	}
+	for i := range grpcRoutes {
+		route := &grpcRoutes[i]
+		key := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		var hostnames []string
+		for _, hostname := range route.Spec.Hostnames {
+			hostnames = append(hostnames, string(hostname))
+		}
+		matches := grpcMethodMatches(route.Spec.Rules)
+		for _, parentRef := range route.Spec.ParentRefs {
+			for _, gw := range topology.parentGateways(route.Namespace, []gatewayapi.ParentReference{parentRef}) {
+				r := gw.CreateGrpcRoute(key.String(), matches...)
+				r.SetHostnames(hostnames...)
+				if parentRef.SectionName != nil {
+					r.SetSectionName(string(*parentRef.SectionName))
+				}
+				topology.GRPCRoutes[key] = append(topology.GRPCRoutes[key], r)
+			}
+		}
+	}
+	for i := range tcpRoutes {
+		route := &tcpRoutes[i]
+		key := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		for _, gw := range topology.parentGateways(route.Namespace, route.Spec.ParentRefs) {
+			topology.TCPRoutes[key] = append(topology.TCPRoutes[key], gw.CreateTcpRoute(key.String()))
+		}
+	}
+	for i := range tlsRoutes {
+		route := &tlsRoutes[i]
+		key := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		var hostnames []string
+		for _, hostname := range route.Spec.Hostnames {
+			hostnames = append(hostnames, string(hostname))
+		}
+		for _, gw := range topology.parentGateways(route.Namespace, route.Spec.ParentRefs) {
+			topology.TLSRoutes[key] = append(topology.TLSRoutes[key], gw.CreateTlsRoute(key.String(), hostnames...))
+		}
+	}
+
+	sorted := make([]*PolicyCR[T], len(policies))
+	for i := range policies {
+		sorted[i] = &policies[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+		}
+		return sorted[i].Namespace+"/"+sorted[i].Name < sorted[j].Namespace+"/"+sorted[j].Name
+	})
+	for _, policy := range sorted {
+		if !topology.attach(policy) {
+			topology.Unattached = append(topology.Unattached, policy)
+		}
+	}
+
+	return topology
+}
+
+// grpcMethodMatches are the methods the rules select, none if any of them selects all of the calls, as do the matches on
+// headers only. Regular expressions aren't supported and select all of the calls too, so that policies are enforced on
+// more calls rather than on fewer.
+func grpcMethodMatches(rules []gatewayapi.GRPCRouteRule) []GrpcMethodMatch {
+	var matches []GrpcMethodMatch
+	for _, rule := range rules {
+		if len(rule.Matches) == 0 {
+			return nil
+		}
+		for _, match := range rule.Matches {
+			if match.Method == nil {
+				return nil
+			}
+			if match.Method.Type != nil && *match.Method.Type == gatewayapi.GRPCMethodMatchRegularExpression {
+				return nil
+			}
+			var method GrpcMethodMatch
+			if match.Method.Service != nil {
+				method.Service = *match.Method.Service
+			}
+			if match.Method.Method != nil {
+				method.Method = *match.Method.Method
+			}
+			matches = append(matches, method)
+		}
+	}
+	return matches
+}
+
+func (t *Topology[T]) parentGateways(namespace string, parentRefs []gatewayapi.ParentReference) []*Gateway[T] {
+	var gateways []*Gateway[T]
+	for _, parentRef := range parentRefs {
+		if parentRef.Kind != nil && *parentRef.Kind != "Gateway" {
+			continue
+		}
+		parent := types.NamespacedName{Namespace: namespace, Name: string(parentRef.Name)}
+		if parentRef.Namespace != nil {
+			parent.Namespace = string(*parentRef.Namespace)
+		}
+		if gw, exists := t.Gateways[parent]; exists {
+			gateways = append(gateways, gw)
+		}
+	}
+	return gateways
+}
+
+func (t *Topology[T]) attach(policy *PolicyCR[T]) bool {
+	target := policy.Spec.TargetRef
+	key := types.NamespacedName{Namespace: policy.Namespace, Name: target.Name}
+	if target.Namespace != "" {
+		key.Namespace = target.Namespace
+	}
+
+	// The topology isn't shared until it's built, the policies' positions can be read without locking the tree.
+	switch target.Kind {
+	case "GatewayClass":
+		if gwc, exists := t.Classes[target.Name]; exists {
+			t.attachments[policy] = append(t.attachments[policy], policyPosition{target: gwc, index: len(gwc.policies)})
+			gwc.AddPolicy(policy.PolicySpec())
+			return true
+		}
+	case "Gateway":
+		if gw, exists := t.Gateways[key]; exists {
+			t.attachments[policy] = append(t.attachments[policy], policyPosition{target: gw, index: len(gw.policies)})
+			gw.AddPolicy(policy.PolicySpec())
+			return true
+		}
+	case "HTTPRoute":
+		if routes, exists := t.Routes[key]; exists {
+			for _, route := range routes {
+				t.attachments[policy] = append(t.attachments[policy], policyPosition{target: route, index: len(route.policies)})
+				route.AddPolicy(policy.PolicySpec())
+			}
+			return true
+		}
+	case "GRPCRoute":
+		if routes, exists := t.GRPCRoutes[key]; exists {
+			for _, route := range routes {
+				t.attachments[policy] = append(t.attachments[policy], policyPosition{target: route, index: len(route.policies)})
+				route.AddPolicy(policy.PolicySpec())
+			}
+			return true
+		}
+	case "TCPRoute":
+		if routes, exists := t.TCPRoutes[key]; exists {
+			for _, route := range routes {
+				t.attachments[policy] = append(t.attachments[policy], policyPosition{target: route, index: len(route.policies)})
+				route.AddPolicy(policy.PolicySpec())
+			}
+			return true
+		}
+	case "TLSRoute":
+		if routes, exists := t.TLSRoutes[key]; exists {
+			for _, route := range routes {
+				t.attachments[policy] = append(t.attachments[policy], policyPosition{target: route, index: len(route.policies)})
+				route.AddPolicy(policy.PolicySpec())
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package gw_policies_playground
+
+import (
+	"context"
+)
+
+type MutationKind string
+
+const (
+	PolicyAttached MutationKind = "PolicyAttached"
+	PolicyDetached MutationKind = "PolicyDetached"
+	RouteCreated   MutationKind = "RouteCreated"
+)
+
+// Mutation is a change to the tree that can change the effective policy of routes. Creating a gateway can't, as it has
+// no routes yet, and neither can attaching policies to a Service, which only affect the backend.
+type Mutation struct {
+	Kind MutationKind `json:"kind"`
+
+	// TargetKind is GatewayClass, Gateway or the kind of the route the policy is attached to, detached from, or that is
+	// created. Gateway is set for routes only.
+	TargetKind string `json:"targetKind"`
+	TargetName string `json:"targetName"`
+	Gateway    string `json:"gateway,omitempty"`
+
+	// Policy is the name of the policy attached or detached.
+	Policy string `json:"policy,omitempty"`
+}
+
+// WatchEvent is a change of the effective policy of a route.
+type WatchEvent[T Policy] struct {
+	Route RouteRef `json:"route"`
+	Old   T        `json:"old"`
+	New   T        `json:"new"`
+
+	// Err is set when merging the policies of the route failed, e.g. as the merger panicked, New being the zero T then.
+	Err error `json:"-"`
+
+	// Mutations may have changed the effective policy from Old to New, in order. There's more than one when the route
+	// changed again before the consumer received the event, or was affected by other mutations before the watcher got to
+	// merge its policies.
+	Mutations []Mutation `json:"mutations"`
+}
+
+// Watch emits an event whenever the effective policy of a route of the class, of any kind, changes from then on. A
+// route created beneath policies changes from the zero T.
+//
+// The tree never waits for the consumer, nor merges on its behalf: a mutation only marks the routes beneath its target,
+// whose policies the watcher merges on its own, sharing the tree's lock with readers. While an event is pending,
+// further changes of the same route are coalesced into it, going from what the consumer last received to the latest
+// effective policy, and an event whose route changed back is dropped. Events of different routes are emitted in the
+// order the routes first changed. The consumer can then call into the tree while handling an event.
+//
+// Canceling ctx unsubscribes and closes the channel.
+func (gwc *GatewayClass[T]) Watch(ctx context.Context, merger func(T, T) T) <-chan WatchEvent[T] {
+	w := &watcher[T]{
+		merger:    merger,
+		wake:      make(chan void, 1),
+		effective: make(map[RouteRef]RouteResult[T]),
+		dirty:     make(map[RouteRef]*dirtyRoute[T]),
+		pending:   make(map[RouteRef]*pendingEvent[T]),
+	}
+
+	gwc.lock.Lock()
+	for _, route := range routesBeneath[T](gwc) {
+		w.effective[routeRefOf(route)] = mergeRoute(route, merger)
+	}
+	gwc.watchers[w] = sentinel
+	gwc.lock.Unlock()
+
+	events := make(chan WatchEvent[T])
+	go func() {
+		defer close(events)
+		defer func() {
+			gwc.lock.Lock()
+			defer gwc.lock.Unlock()
+			delete(gwc.watchers, w)
+		}()
+
+		for {
+			gwc.lock.RLock()
+			w.refresh()
+			gwc.lock.RUnlock()
+
+			event, ok := w.next()
+			if !ok {
+				select {
+				case <-ctx.Done():
+					return
+				case <-w.wake:
+				}
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case events <- event:
+			}
+		}
+	}()
+	return events
+}
+
+// watcher holds the effective policy of every route, as of its last refresh, and the events yet to be emitted, which
+// only its goroutine touches. The routes marked dirty are shared with the mutations, under the tree's lock.
+type watcher[T Policy] struct {
+	merger func(T, T) T
+	wake   chan void
+
+	dirty      map[RouteRef]*dirtyRoute[T]
+	dirtyOrder []RouteRef
+
+	effective map[RouteRef]RouteResult[T]
+	pending   map[RouteRef]*pendingEvent[T]
+	order     []RouteRef
+}
+
+type dirtyRoute[T Policy] struct {
+	route     routeNode[T]
+	mutations []Mutation
+}
+
+type pendingEvent[T Policy] struct {
+	old       RouteResult[T]
+	mutations []Mutation
+}
+
+// notify expects the caller to hold the tree's write lock, the mutation of target being done. It marks the routes
+// beneath target for every watcher to merge them again.
+func (gwc *GatewayClass[T]) notify(target interface{}, mutation Mutation) {
+	if len(gwc.watchers) == 0 {
+		return
+	}
+	routes := routesBeneath[T](target)
+	if len(routes) == 0 {
+		return
+	}
+	for w := range gwc.watchers {
+		for _, route := range routes {
+			ref := routeRefOf(route)
+			dirty, exists := w.dirty[ref]
+			if !exists {
+				dirty = &dirtyRoute[T]{route: route}
+				w.dirty[ref] = dirty
+				w.dirtyOrder = append(w.dirtyOrder, ref)
+			}
+			dirty.mutations = append(dirty.mutations, mutation)
+		}
+		select {
+		case w.wake <- sentinel:
+		default:
+		}
+	}
+}
+
+// refresh merges the policies of the dirty routes, turning the changes into pending events. It expects the caller to
+// hold the tree's lock, for reading.
+func (w *watcher[T]) refresh() {
+	for _, ref := range w.dirtyOrder {
+		dirty := w.dirty[ref]
+		delete(w.dirty, ref)
+
+		result := mergeRoute(dirty.route, w.merger)
+		previous := w.effective[ref]
+		if sameRouteResult(previous, result) {
+			continue
+		}
+		w.effective[ref] = result
+
+		event, exists := w.pending[ref]
+		if !exists {
+			event = &pendingEvent[T]{old: previous}
+			w.pending[ref] = event
+			w.order = append(w.order, ref)
+		}
+		event.mutations = append(event.mutations, dirty.mutations...)
+	}
+	w.dirtyOrder = nil
+}
+
+// next pops the oldest pending event, skipping those whose route is back to what the consumer last received.
+func (w *watcher[T]) next() (WatchEvent[T], bool) {
+	for len(w.order) > 0 {
+		ref := w.order[0]
+		w.order = w.order[1:]
+		event := w.pending[ref]
+		delete(w.pending, ref)
+
+		current := w.effective[ref]
+		if sameRouteResult(event.old, current) {
+			continue
+		}
+		return WatchEvent[T]{Route: ref, Old: event.old.Policy, New: current.Policy, Err: current.Err, Mutations: event.mutations}, true
+	}
+	return WatchEvent[T]{}, false
+}
+
+// sameRouteResult tells whether both merges failed the same way, or succeeded with the same policy.
+func sameRouteResult[T Policy](a, b RouteResult[T]) bool {
+	if a.Err != nil || b.Err != nil {
+		return a.Err != nil && b.Err != nil && a.Err.Error() == b.Err.Error()
+	}
+	return len(DiffPolicies(a.Policy, b.Policy)) == 0
+}
@@ -0,0 +1,172 @@
+package gw_policies_playground
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func testRateLimit(limit int) RateLimitPolicy {
+	return RateLimitPolicy{Limits: map[string]Limit{"global": {Rates: []Rate{{Limit: limit, Duration: 1, Unit: "minute"}}}}}
+}
+
+func receiveWatchEvent(t *testing.T, events <-chan WatchEvent[RateLimitPolicy]) WatchEvent[RateLimitPolicy] {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+		return WatchEvent[RateLimitPolicy]{}
+	}
+}
+
+func assertNoWatchEvent(t *testing.T, events <-chan WatchEvent[RateLimitPolicy]) {
+	t.Helper()
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event for %s: %v", event.Route, event.Mutations)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestWatch(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw := gwc.CreateGateway("gw")
+	toystore := gw.CreateRoute("toystore")
+	toystore.AddPolicy(PolicySpec[RateLimitPolicy]{name: "route", defaults: testRateLimit(10)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := gwc.Watch(ctx, RateLimitPolicyMerger)
+
+	gw.AddPolicy(PolicySpec[RateLimitPolicy]{name: "gw", overrides: testRateLimit(5)})
+	event := receiveWatchEvent(t, events)
+	assert.Equal(t, event.Route, RouteRef{Gateway: "gw", Route: "toystore"})
+	assert.Equal(t, event.Old.Limits["global"].Rates[0].Limit, 10)
+	assert.Equal(t, event.New.Limits["global"].Rates[0].Limit, 5)
+	assert.DeepEqual(t, event.Mutations, []Mutation{{Kind: PolicyAttached, TargetKind: "Gateway", TargetName: "gw", Policy: "gw"}})
+
+	// The route policy is overridden, so detaching it changes nothing.
+	assert.Check(t, toystore.RemovePolicy("route"))
+	assertNoWatchEvent(t, events)
+
+	gw.CreateTcpRoute("db")
+	gw.CreateGrpcRoute("catalog")
+	event = receiveWatchEvent(t, events)
+	assert.Equal(t, event.Route, RouteRef{Gateway: "gw", Route: "db", Kind: TCPRouteKind})
+	assert.Equal(t, event.Mutations[0].Kind, RouteCreated)
+	event = receiveWatchEvent(t, events)
+	assert.Equal(t, event.Route, RouteRef{Gateway: "gw", Route: "catalog", Kind: GRPCRouteKind})
+	assert.Equal(t, len(event.Old.Limits), 0)
+	assert.Equal(t, event.New.Limits["global"].Rates[0].Limit, 5)
+	assert.DeepEqual(t, event.Mutations, []Mutation{{Kind: RouteCreated, TargetKind: "GRPCRoute", TargetName: "catalog", Gateway: "gw"}})
+	assertNoWatchEvent(t, events)
+
+	assert.Check(t, gw.RemovePolicy("gw"))
+	event = receiveWatchEvent(t, events)
+	assert.Equal(t, event.Route.Route, "toystore")
+	assert.Equal(t, len(event.New.Limits), 0)
+	assert.Equal(t, event.Mutations[0].Kind, PolicyDetached)
+	for _, route := range []string{"catalog", "db"} {
+		event = receiveWatchEvent(t, events)
+		assert.Equal(t, event.Route.Route, route)
+	}
+
+	cancel()
+	_, open := <-events
+	assert.Check(t, !open)
+	gwc.AddPolicy(PolicySpec[RateLimitPolicy]{name: "class", defaults: testRateLimit(1)})
+	gwc.lock.RLock()
+	defer gwc.lock.RUnlock()
+	assert.Equal(t, len(gwc.watchers), 0)
+}
+
+func TestWatch_SlowConsumer(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw := gwc.CreateGateway("gw")
+	toystore := gw.CreateRoute("toystore")
+	other := gw.CreateRoute("other")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := gwc.Watch(ctx, RateLimitPolicyMerger)
+
+	// Nothing is received until all of these are done, and the consumer calls into the tree in between.
+	toystore.AddPolicy(PolicySpec[RateLimitPolicy]{name: "first", defaults: testRateLimit(10)})
+	other.AddPolicy(PolicySpec[RateLimitPolicy]{name: "other", defaults: testRateLimit(100)})
+	toystore.AddPolicy(PolicySpec[RateLimitPolicy]{name: "second", overrides: testRateLimit(5)})
+	other.RemovePolicy("other")
+
+	event := receiveWatchEvent(t, events)
+	assert.Equal(t, event.Route.Route, "toystore")
+	assert.Equal(t, len(event.Old.Limits), 0)
+	assert.Equal(t, event.New.Limits["global"].Rates[0].Limit, 5)
+	assert.Equal(t, len(event.Mutations), 2)
+	assert.Equal(t, event.Mutations[0].Policy, "first")
+	assert.Equal(t, event.Mutations[1].Policy, "second")
+	assert.Equal(t, toystore.MergedPolicies(RateLimitPolicyMerger).Limits["global"].Rates[0].Limit, 5)
+
+	// The other route changed back to what the consumer knows.
+	assertNoWatchEvent(t, events)
+}
+
+func TestWatch_AdmitPolicy(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw := gwc.CreateGateway("gw")
+	catalog := gw.CreateGrpcRoute("catalog")
+	gw.AddConstraint(NewConstraint("at-most-100", func(policy RateLimitPolicy) []string {
+		if limit, exists := policy.Limits["global"]; exists && limit.Rates[0].Limit > 100 {
+			return []string{"more than 100 requests per minute"}
+		}
+		return nil
+	}, true))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := gwc.Watch(ctx, RateLimitPolicyMerger)
+
+	assert.NilError(t, gw.AdmitPolicy(PolicySpec[RateLimitPolicy]{name: "gw", defaults: testRateLimit(10)}, RateLimitPolicyMerger))
+	event := receiveWatchEvent(t, events)
+	assert.DeepEqual(t, event.Mutations, []Mutation{{Kind: PolicyAttached, TargetKind: "Gateway", TargetName: "gw", Policy: "gw"}})
+
+	assert.Check(t, catalog.AdmitPolicy(PolicySpec[RateLimitPolicy]{name: "too-many", overrides: testRateLimit(1000)}, RateLimitPolicyMerger) != nil)
+	assert.NilError(t, catalog.AdmitPolicy(PolicySpec[RateLimitPolicy]{name: "catalog", overrides: testRateLimit(5)}, RateLimitPolicyMerger))
+	event = receiveWatchEvent(t, events)
+	assert.Equal(t, event.Old.Limits["global"].Rates[0].Limit, 10)
+	assert.Equal(t, event.New.Limits["global"].Rates[0].Limit, 5)
+	assert.DeepEqual(t, event.Mutations, []Mutation{
+		{Kind: PolicyAttached, TargetKind: "GRPCRoute", TargetName: "catalog", Gateway: "gw", Policy: "catalog"},
+	})
+}
+
+func TestWatch_PanickingMerger(t *testing.T) {
+	gwc := NewGatewayClass[RateLimitPolicy]("gwc")
+	gw := gwc.CreateGateway("gw")
+	toystore := gw.CreateRoute("toystore")
+	toystore.AddPolicy(PolicySpec[RateLimitPolicy]{name: "route", defaults: testRateLimit(10)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := gwc.Watch(ctx, func(p1, p2 RateLimitPolicy) RateLimitPolicy {
+		if limit, exists := p1.Limits["global"]; exists && limit.Rates[0].Limit == 0 {
+			panic("no requests at all")
+		}
+		return RateLimitPolicyMerger(p1, p2)
+	})
+
+	// The mutation is done, whatever the watcher makes of it.
+	gw.AddPolicy(PolicySpec[RateLimitPolicy]{name: "closed", overrides: testRateLimit(0)})
+	event := receiveWatchEvent(t, events)
+	assert.Equal(t, event.Old.Limits["global"].Rates[0].Limit, 10)
+	assert.Equal(t, len(event.New.Limits), 0)
+	assert.Error(t, event.Err, "merging gw/toystore: no requests at all")
+	assert.Equal(t, toystore.MergedPolicies(RateLimitPolicyMerger).Limits["global"].Rates[0].Limit, 0)
+
+	assert.Check(t, gw.RemovePolicy("closed"))
+	event = receiveWatchEvent(t, events)
+	assert.NilError(t, event.Err)
+	assert.Equal(t, event.New.Limits["global"].Rates[0].Limit, 10)
+}